@@ -33,6 +33,11 @@ type Config struct {
 	TuningSets []TuningSet `json: tuningSets`
 	// ChaosMonkey is a config for simulated component failures.
 	ChaosMonkey ChaosMonkeyConfig `json: chaosMonkey`
+	// Identities is a collection of identities that can be impersonated by phases.
+	Identities []Identity `json:"identities"`
+	// PrometheusObjects is a collection of ServiceMonitors and PrometheusRules
+	// added to the prometheus stack for the duration of the test.
+	PrometheusObjects []PrometheusObject `json:"prometheusObjects"`
 }
 
 // Step represents encapsulation of some actions. These actions could be
@@ -66,6 +71,9 @@ type Phase struct {
 	// For every specified namespace and for every required replica,
 	// these objects will be reconciled in serial.
 	ObjectBundle []Object `json: objectBundle`
+	// Identity is the name of the Identity to be impersonated while reconciling objects.
+	// If empty, the identity from kubeconfig is used.
+	Identity string `json:"identity"`
 }
 
 // Object is a structure that defines the object managed be the tests.
@@ -78,6 +86,14 @@ type Object struct {
 	TemplateFillMap map[string]interface{} `json: templateFillMap`
 }
 
+// PrometheusObject is a structure that defines the object extending the prometheus stack.
+type PrometheusObject struct {
+	// ObjectTemplatePath specifies the path to object definition.
+	ObjectTemplatePath string `json:"objectTemplatePath"`
+	// TemplateFillMap specifies for each placeholder what value should it be replaced with.
+	TemplateFillMap map[string]interface{} `json:"templateFillMap"`
+}
+
 // NamespaceRange specifies the range of namespaces [Min, Max].
 type NamespaceRange struct {
 	// Min is the lower index of namespace range.
@@ -112,6 +128,20 @@ type TuningSet struct {
 	ParallelismLimitedLoad *ParallelismLimitedLoad `json: parallelismLimitedLoad`
 }
 
+// Identity defines a user or a service account impersonated by the clients.
+// Exactly one of User and ServiceAccount should be set.
+// The identity from kubeconfig has to be allowed to impersonate it.
+type Identity struct {
+	// Name by which the Identity will be referenced.
+	Name string `json:"name"`
+	// User is the name of impersonated user.
+	User string `json:"user"`
+	// ServiceAccount is the impersonated service account in <namespace>/<name> format.
+	ServiceAccount string `json:"serviceAccount"`
+	// Groups is a list of additionally impersonated groups.
+	Groups []string `json:"groups"`
+}
+
 // Measurement is a structure that defines the measurement method call.
 // This method call will either start or stop process of collecting specific data samples.
 type Measurement struct {
@@ -167,6 +197,14 @@ type ParallelismLimitedLoad struct {
 type ChaosMonkeyConfig struct {
 	// NodeFailure is a config for simulated node failures.
 	NodeFailure *NodeFailureConfig `json: nodeFailure`
+	// PodFailure is a config for simulated pod failures.
+	PodFailure *PodFailureConfig `json:"podFailure"`
+	// ControlPlaneFailure is a config for simulated control plane component failures.
+	ControlPlaneFailure *ControlPlaneFailureConfig `json:"controlPlaneFailure"`
+	// NodeDrain is a config for simulated node drains.
+	NodeDrain *NodeDrainConfig `json:"nodeDrain"`
+	// NetworkPartition is a config for simulated network partitions.
+	NetworkPartition *NetworkPartitionConfig `json:"networkPartition"`
 }
 
 // NodeFailureConfig describes simulated node failures.
@@ -180,6 +218,68 @@ type NodeFailureConfig struct {
 	JitterFactor float64 `json: jitterFactor`
 	// SimulatedDowntime is a duration between node is killed and recreated.
 	SimulatedDowntime Duration `json: simulatedDowntime`
+	// Actuator is the method of simulating node failure: ssh, cloud, kubemark or taint.
+	// If empty, ssh is used for gce and gke, kubemark for kubemark and taint for other providers.
+	Actuator string `json:"actuator"`
+}
+
+// PodFailureConfig describes simulated pod failures (pod deletions).
+type PodFailureConfig struct {
+	// Namespace of the pods. If empty, pods from all namespaces are taken into account.
+	Namespace string `json:"namespace"`
+	// LabelSelector of the pods.
+	LabelSelector string `json:"labelSelector"`
+	// FailureRate is a percentage of the selected pods deleted every interval.
+	FailureRate float64 `json:"failureRate"`
+	// Interval is time between pod failures.
+	Interval Duration `json:"interval"`
+	// JitterFactor is factor used to jitter pod failures.
+	JitterFactor float64 `json:"jitterFactor"`
+}
+
+// ControlPlaneFailureConfig describes simulated control plane component failures
+// (deletions of the kube-system pods with component label).
+type ControlPlaneFailureConfig struct {
+	// Components are the names of the components, kube-scheduler and kube-controller-manager by default.
+	Components []string `json:"components"`
+	// FailureRate is a percentage of the component pods deleted every interval.
+	FailureRate float64 `json:"failureRate"`
+	// Interval is time between component failures.
+	Interval Duration `json:"interval"`
+	// JitterFactor is factor used to jitter component failures.
+	JitterFactor float64 `json:"jitterFactor"`
+}
+
+// NodeDrainConfig describes simulated node drains.
+type NodeDrainConfig struct {
+	// FailureRate is a percentage of all nodes that could be drained simultaneously.
+	FailureRate float64 `json:"failureRate"`
+	// Interval is time between node drains.
+	Interval Duration `json:"interval"`
+	// JitterFactor is factor used to jitter node drains.
+	JitterFactor float64 `json:"jitterFactor"`
+	// DrainDuration is a duration between node is cordoned and uncordoned.
+	DrainDuration Duration `json:"drainDuration"`
+	// CordonOnly disables eviction of pods from the cordoned nodes.
+	CordonOnly bool `json:"cordonOnly"`
+}
+
+// NetworkPartitionConfig describes simulated network partitions.
+// Partitioned pods are isolated with deny-all NetworkPolicy,
+// so network plugin enforcing network policies is required.
+type NetworkPartitionConfig struct {
+	// Namespace of the pods.
+	Namespace string `json:"namespace"`
+	// LabelSelector of the pods.
+	LabelSelector string `json:"labelSelector"`
+	// FailureRate is a percentage of the selected pods partitioned every interval.
+	FailureRate float64 `json:"failureRate"`
+	// Interval is time between partitions.
+	Interval Duration `json:"interval"`
+	// JitterFactor is factor used to jitter partitions.
+	JitterFactor float64 `json:"jitterFactor"`
+	// PartitionDuration is a duration after which partition is healed.
+	PartitionDuration Duration `json:"partitionDuration"`
 }
 
 // Duration is time.Duration that uses string format (e.g. 1h2m3s) for marshaling.
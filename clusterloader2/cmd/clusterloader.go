@@ -17,7 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"time"
@@ -33,7 +36,10 @@ import (
 	"k8s.io/perf-tests/clusterloader2/pkg/execservice"
 	"k8s.io/perf-tests/clusterloader2/pkg/flags"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+	frameworkconfig "k8s.io/perf-tests/clusterloader2/pkg/framework/config"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
+	"k8s.io/perf-tests/clusterloader2/pkg/sweep"
 	"k8s.io/perf-tests/clusterloader2/pkg/test"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 
@@ -44,13 +50,18 @@ import (
 )
 
 const (
-	dashLine        = "--------------------------------------------------------------------------------"
-	nodesPerClients = 100
+	dashLine           = "--------------------------------------------------------------------------------"
+	nodesPerClients    = 100
+	runManifestName    = "RunManifest.json"
+	mappingName        = "TemplateMapping.json"
+	sweepTableName     = "SweepSummary.txt"
+	prometheusDataName = "PrometheusData.json"
 )
 
 var (
 	clusterLoaderConfig config.ClusterLoaderConfig
 	testConfigPaths     []string
+	sweepPath           string
 )
 
 func initClusterFlags() {
@@ -63,6 +74,20 @@ func initClusterFlags() {
 	flags.StringSliceEnvVar(&clusterLoaderConfig.ClusterConfig.MasterInternalIPs, "master-internal-ip", "MASTER_INTERNAL_IP", nil /*defaultValue*/, "Cluster internal/private IP of the master vm, supports multiple values when separated by commas")
 	flags.StringEnvVar(&clusterLoaderConfig.ClusterConfig.KubemarkRootKubeConfigPath, "kubemark-root-kubeconfig", "KUBEMARK_ROOT_KUBECONFIG", "",
 		"Path the to kubemark root kubeconfig file, i.e. kubeconfig of the cluster where kubemark cluster is run. Ignored if provider != kubemark")
+	flags.StringEnvVar(&clusterLoaderConfig.ClusterConfig.MasterAccess, "master-access", "MASTER_ACCESS", "",
+		"Method of accessing master components by measurements: ssh, proxy, exec or http. If not provided, method is chosen based on the provider")
+	initClientFlags()
+}
+
+func initClientFlags() {
+	clientConfig := &clusterLoaderConfig.ClusterConfig.ClientConfig
+	flags.IntEnvVar(&clientConfig.QPS, "client-qps", "CLIENT_QPS", frameworkconfig.DefaultQPS, "QPS limit of every kubernetes client, negative value disables client-side throttling")
+	flags.IntEnvVar(&clientConfig.Burst, "client-burst", "CLIENT_BURST", frameworkconfig.DefaultBurst, "Burst limit of every kubernetes client")
+	flags.StringEnvVar(&clientConfig.ContentType, "client-content-type", "CLIENT_CONTENT_TYPE", frameworkconfig.ContentTypeProtobuf,
+		fmt.Sprintf("Content type used by kubernetes clients, either %q or %q", frameworkconfig.ContentTypeProtobuf, frameworkconfig.ContentTypeJSON))
+	flags.IntEnvVar(&clientConfig.ClientsNumber, "clients-number", "CLIENTS_NUMBER", 0,
+		fmt.Sprintf("Number of kubernetes clients used by the tests. If not provided, one client per %d nodes is used", nodesPerClients))
+	flags.StringEnvVar(&clientConfig.UserAgent, "client-user-agent", "CLIENT_USER_AGENT", "", "User agent of kubernetes clients. If not provided, default one is used")
 }
 
 func validateClusterFlags() *errors.ErrorList {
@@ -75,6 +100,12 @@ func validateClusterFlags() *errors.ErrorList {
 		clusterLoaderConfig.ClusterConfig.KubemarkRootKubeConfigPath == "" {
 		errList.Append(fmt.Errorf("no kubemark-root-kubeconfig path specified"))
 	}
+	if !masteraccess.IsValidMethod(clusterLoaderConfig.ClusterConfig.MasterAccess) {
+		errList.Append(fmt.Errorf("unknown master access method %q", clusterLoaderConfig.ClusterConfig.MasterAccess))
+	}
+	if err := frameworkconfig.ValidateClientConfig(&clusterLoaderConfig.ClusterConfig.ClientConfig); err != nil {
+		errList.Append(err)
+	}
 	return errList
 }
 
@@ -83,8 +114,15 @@ func initFlags() {
 	flags.BoolEnvVar(&clusterLoaderConfig.EnablePrometheusServer, "enable-prometheus-server", "ENABLE_PROMETHEUS_SERVER", false, "Whether to set-up the prometheus server in the cluster.")
 	flags.BoolEnvVar(&clusterLoaderConfig.EnableExecService, "enable-exec-service", "ENABLE_EXEC_SERVICE", false, "Whether to enable exec service that allows executing arbitrary commands from a pod running in the cluster.")
 	flags.BoolEnvVar(&clusterLoaderConfig.TearDownPrometheusServer, "tear-down-prometheus-server", "TEAR_DOWN_PROMETHEUS_SERVER", true, "Whether to tear-down the prometheus server after tests (if set-up).")
+	flags.StringEnvVar(&clusterLoaderConfig.PrometheusURL, "prometheus-url", "PROMETHEUS_URL", "", "Address of an existing Prometheus server to be queried instead of setting up the prometheus stack.")
+	flags.StringArrayVar(&clusterLoaderConfig.PrometheusExportQueries, "prometheus-export-query", []string{}, "Prometheus query whose results over the whole run are exported to the report dir. This flag can be used multiple times.")
+	flags.DurationVar(&clusterLoaderConfig.PrometheusExportStep, "prometheus-export-step", 30*time.Second, "Resolution of the exported prometheus query results.")
+	flags.BoolVar(&clusterLoaderConfig.PrometheusExportSnapshot, "prometheus-export-snapshot", false, "Whether to export TSDB snapshot of the prometheus server set up in the cluster to the report dir.")
+	flags.StringEnvVar(&clusterLoaderConfig.PrometheusBearerTokenFile, "prometheus-bearer-token-file", "PROMETHEUS_BEARER_TOKEN_FILE", "", "Path to the file with bearer token used to authenticate to the Prometheus server given by --prometheus-url.")
 	flags.StringArrayVar(&testConfigPaths, "testconfig", []string{}, "Paths to the test config files")
 	flags.StringArrayVar(&clusterLoaderConfig.TestOverridesPath, "testoverrides", []string{}, "Paths to the config overrides file. The latter overrides take precedence over changes in former files.")
+	flags.StringVar(&sweepPath, "sweep", "", "Path to the sweep file. If provided, tests are run for every combination of template variables defined in it.")
+	flags.StringArrayVar(&clusterLoaderConfig.OverrideValues, "set", []string{}, "Template variables in KEY=VALUE form. Take precedence over override files and CL2_ prefixed env variables.")
 	initClusterFlags()
 }
 
@@ -93,6 +131,28 @@ func validateFlags() *errors.ErrorList {
 	if len(testConfigPaths) < 1 {
 		errList.Append(fmt.Errorf("no test config path specified"))
 	}
+	if sweepPath != "" && clusterLoaderConfig.ReportDir == "" {
+		errList.Append(fmt.Errorf("report dir has to be specified in sweep mode"))
+	}
+	if clusterLoaderConfig.PrometheusURL != "" {
+		if clusterLoaderConfig.EnablePrometheusServer {
+			errList.Append(fmt.Errorf("prometheus server can't be set up when prometheus url is specified"))
+		}
+		if u, err := url.Parse(clusterLoaderConfig.PrometheusURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errList.Append(fmt.Errorf("invalid prometheus url %q", clusterLoaderConfig.PrometheusURL))
+		}
+	} else if clusterLoaderConfig.PrometheusBearerTokenFile != "" {
+		errList.Append(fmt.Errorf("prometheus bearer token file requires prometheus url"))
+	}
+	if (len(clusterLoaderConfig.PrometheusExportQueries) > 0 || clusterLoaderConfig.PrometheusExportSnapshot) && clusterLoaderConfig.ReportDir == "" {
+		errList.Append(fmt.Errorf("report dir has to be specified to export prometheus data"))
+	}
+	if clusterLoaderConfig.PrometheusExportStep <= 0 {
+		errList.Append(fmt.Errorf("prometheus export step has to be positive"))
+	}
+	if clusterLoaderConfig.PrometheusExportSnapshot && !clusterLoaderConfig.EnablePrometheusServer {
+		errList.Append(fmt.Errorf("prometheus snapshot can be exported only if prometheus server is set up"))
+	}
 	errList.Concat(validateClusterFlags())
 	return errList
 }
@@ -106,6 +166,11 @@ func completeConfig(m *framework.MultiClientSet) error {
 		clusterLoaderConfig.ClusterConfig.Nodes = nodes
 		klog.Infof("ClusterConfig.Nodes set to %v", nodes)
 	}
+	if clusterLoaderConfig.ClusterConfig.ClientConfig.ClientsNumber == 0 {
+		clientsNumber := getClientsNumber(clusterLoaderConfig.ClusterConfig.Nodes)
+		clusterLoaderConfig.ClusterConfig.ClientConfig.ClientsNumber = clientsNumber
+		klog.Infof("ClusterConfig.ClientConfig.ClientsNumber set to %v", clientsNumber)
+	}
 	if clusterLoaderConfig.ClusterConfig.MasterName == "" {
 		masterName, err := util.GetMasterName(m.GetClient())
 		if err == nil {
@@ -165,6 +230,88 @@ func createReportDir() error {
 	return nil
 }
 
+// writeRunManifest saves the configuration of the run (including client settings) in the report directory.
+func writeRunManifest() error {
+	if clusterLoaderConfig.ReportDir == "" {
+		return nil
+	}
+	manifest, err := util.PrettyPrintJSON(clusterLoaderConfig)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(clusterLoaderConfig.ReportDir, runManifestName), []byte(manifest), 0644)
+}
+
+// logAndWriteMapping prints template variable mapping and saves it in the report directory.
+func logAndWriteMapping() error {
+	mapping, errList := config.GetMapping(&clusterLoaderConfig)
+	if errList != nil {
+		return errList
+	}
+	printable := make(map[string]interface{}, len(mapping))
+	for k, v := range mapping {
+		// Durations would be serialized as nanoseconds otherwise.
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		printable[k] = v
+	}
+	klog.Infof("Using template mapping: %v", printable)
+	if clusterLoaderConfig.ReportDir == "" {
+		return nil
+	}
+	content, err := util.PrettyPrintJSON(printable)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(clusterLoaderConfig.ReportDir, mappingName), []byte(content), 0644)
+}
+
+// exportPrometheusData exports results of the configured queries over [start, end]
+// and the TSDB snapshot (if enabled) to the report dir.
+func exportPrometheusData(prometheusController *prometheus.PrometheusController, prometheusFramework *framework.Framework, start, end time.Time) {
+	if len(clusterLoaderConfig.PrometheusExportQueries) > 0 {
+		if c := prometheus.NewClient(&clusterLoaderConfig, prometheusFramework); c != nil {
+			fileName := path.Join(clusterLoaderConfig.ReportDir, prometheusDataName)
+			if err := prometheus.ExportRangeQueries(c, clusterLoaderConfig.PrometheusExportQueries, start, end, clusterLoaderConfig.PrometheusExportStep, fileName); err != nil {
+				klog.Errorf("Error while exporting prometheus queries: %v", err)
+			}
+		} else {
+			klog.Warning("Prometheus is disabled, skipping the export of prometheus queries")
+		}
+	}
+	if clusterLoaderConfig.PrometheusExportSnapshot && prometheusController != nil {
+		if err := prometheusController.ExportSnapshot(clusterLoaderConfig.ReportDir); err != nil {
+			klog.Errorf("Error while exporting prometheus snapshot: %v", err)
+		}
+	}
+}
+
+// listReportFiles returns set of files in the report directory.
+func listReportFiles() (map[string]bool, error) {
+	files := make(map[string]bool)
+	infos, err := ioutil.ReadDir(clusterLoaderConfig.ReportDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			files[path.Join(clusterLoaderConfig.ReportDir, info.Name())] = true
+		}
+	}
+	return files, nil
+}
+
+// writeSweepTable prints table of sweep results and saves it in the report directory.
+func writeSweepTable(testSweep *sweep.Sweep, results []sweep.Result) error {
+	var b bytes.Buffer
+	if err := testSweep.WriteTable(&b, results); err != nil {
+		return err
+	}
+	klog.Infof("Sweep results:\n%s", b.String())
+	return ioutil.WriteFile(path.Join(clusterLoaderConfig.ReportDir, sweepTableName), b.Bytes(), 0644)
+}
+
 func printTestStart(name string) {
 	klog.Infof(dashLine)
 	klog.Infof("Running %v", name)
@@ -196,7 +343,18 @@ func main() {
 		klog.Exitf("Parsing flags error: %v", errList.String())
 	}
 
-	mclient, err := framework.NewMultiClientSet(clusterLoaderConfig.ClusterConfig.KubeConfigPath, 1)
+	// Sweep with a single empty combination corresponds to a regular run.
+	combinations := []sweep.Combination{{}}
+	var testSweep *sweep.Sweep
+	if sweepPath != "" {
+		var err error
+		if testSweep, err = sweep.Load(sweepPath); err != nil {
+			klog.Exitf("Sweep loading error: %v", err)
+		}
+		combinations = testSweep.Combinations()
+	}
+
+	mclient, err := framework.NewMultiClientSet(clusterLoaderConfig.ClusterConfig.KubeConfigPath, 1, &clusterLoaderConfig.ClusterConfig.ClientConfig)
 	if err != nil {
 		klog.Exitf("Client creation error: %v", err)
 	}
@@ -211,6 +369,14 @@ func main() {
 		klog.Exitf("Cannot create report directory: %v", err)
 	}
 
+	if err = writeRunManifest(); err != nil {
+		klog.Errorf("Run manifest writing error: %v", err)
+	}
+
+	if err = logAndWriteMapping(); err != nil {
+		klog.Exitf("Template mapping error: %v", err)
+	}
+
 	if err = util.LogClusterNodes(mclient.GetClient()); err != nil {
 		klog.Errorf("Nodes info logging error: %v", err)
 	}
@@ -221,7 +387,7 @@ func main() {
 
 	f, err := framework.NewFramework(
 		&clusterLoaderConfig.ClusterConfig,
-		getClientsNumber(clusterLoaderConfig.ClusterConfig.Nodes),
+		clusterLoaderConfig.ClusterConfig.ClientConfig.ClientsNumber,
 	)
 	if err != nil {
 		klog.Exitf("Framework creation error: %v", err)
@@ -237,6 +403,8 @@ func main() {
 		if err := prometheusController.SetUpPrometheusStack(); err != nil {
 			klog.Exitf("Error while setting up prometheus stack: %v", err)
 		}
+	} else if clusterLoaderConfig.PrometheusURL != "" {
+		klog.Infof("Using prometheus server at %s", clusterLoaderConfig.PrometheusURL)
 	}
 	if clusterLoaderConfig.EnableExecService {
 		if err := execservice.SetUpExecService(f); err != nil {
@@ -246,34 +414,83 @@ func main() {
 
 	suiteSummary := &ginkgotypes.SuiteSummary{
 		SuiteDescription:           "ClusterLoaderV2",
-		NumberOfSpecsThatWillBeRun: len(testConfigPaths),
+		NumberOfSpecsThatWillBeRun: len(combinations) * len(testConfigPaths),
 	}
 	junitReporter := ginkgoreporters.NewJUnitReporter(path.Join(clusterLoaderConfig.ReportDir, "junit.xml"))
 	junitReporter.SpecSuiteWillBegin(ginkgoconfig.GinkgoConfig, suiteSummary)
 	testsStart := time.Now()
-	for _, clusterLoaderConfig.TestConfigPath = range testConfigPaths {
-		testStart := time.Now()
-		specSummary := &ginkgotypes.SpecSummary{
-			ComponentTexts: []string{suiteSummary.SuiteDescription, clusterLoaderConfig.TestConfigPath},
+	baseReportDir := clusterLoaderConfig.ReportDir
+	var sweepResults []sweep.Result
+	for _, combination := range combinations {
+		componentTexts := []string{suiteSummary.SuiteDescription}
+		if testSweep != nil {
+			componentTexts = append(componentTexts, combination.Name)
+			clusterLoaderConfig.SweepValues = combination.Values
+			clusterLoaderConfig.ReportDir = path.Join(baseReportDir, combination.Dir())
+			if err = createReportDir(); err != nil {
+				klog.Exitf("Cannot create report directory: %v", err)
+			}
+			if err = logAndWriteMapping(); err != nil {
+				klog.Exitf("Template mapping error: %v", err)
+			}
 		}
-		printTestStart(clusterLoaderConfig.TestConfigPath)
-		if errList := test.RunTest(f, prometheusFramework, &clusterLoaderConfig); !errList.IsEmpty() {
-			suiteSummary.NumberOfFailedSpecs++
-			specSummary.State = ginkgotypes.SpecStateFailed
-			specSummary.Failure = ginkgotypes.SpecFailure{
-				Message: errList.String(),
+		for _, clusterLoaderConfig.TestConfigPath = range testConfigPaths {
+			testStart := time.Now()
+			specSummary := &ginkgotypes.SpecSummary{
+				ComponentTexts: append(append([]string{}, componentTexts...), clusterLoaderConfig.TestConfigPath),
+			}
+			var filesBefore map[string]bool
+			if testSweep != nil {
+				if filesBefore, err = listReportFiles(); err != nil {
+					klog.Errorf("Listing report files error: %v", err)
+				}
+			}
+			printTestStart(clusterLoaderConfig.TestConfigPath)
+			if errList := test.RunTest(f, prometheusController, &clusterLoaderConfig); !errList.IsEmpty() {
+				suiteSummary.NumberOfFailedSpecs++
+				specSummary.State = ginkgotypes.SpecStateFailed
+				specSummary.Failure = ginkgotypes.SpecFailure{
+					Message: errList.String(),
+				}
+				printTestResult(clusterLoaderConfig.TestConfigPath, "Fail", errList.String())
+			} else {
+				specSummary.State = ginkgotypes.SpecStatePassed
+				printTestResult(clusterLoaderConfig.TestConfigPath, "Success", "")
+			}
+			specSummary.RunTime = time.Since(testStart)
+			junitReporter.SpecDidComplete(specSummary)
+
+			if testSweep != nil {
+				filesAfter, err := listReportFiles()
+				if err != nil {
+					klog.Errorf("Listing report files error: %v", err)
+				}
+				var newFiles []string
+				for file := range filesAfter {
+					if !filesBefore[file] {
+						newFiles = append(newFiles, file)
+					}
+				}
+				sweepResults = append(sweepResults, sweep.Result{
+					Combination: combination.Name,
+					Test:        clusterLoaderConfig.TestConfigPath,
+					Passed:      specSummary.State == ginkgotypes.SpecStatePassed,
+					Duration:    specSummary.RunTime,
+					Metrics:     testSweep.ExtractMetrics(newFiles),
+				})
 			}
-			printTestResult(clusterLoaderConfig.TestConfigPath, "Fail", errList.String())
-		} else {
-			specSummary.State = ginkgotypes.SpecStatePassed
-			printTestResult(clusterLoaderConfig.TestConfigPath, "Success", "")
 		}
-		specSummary.RunTime = time.Since(testStart)
-		junitReporter.SpecDidComplete(specSummary)
 	}
+	clusterLoaderConfig.ReportDir = baseReportDir
 	suiteSummary.RunTime = time.Since(testsStart)
 	junitReporter.SpecSuiteDidEnd(suiteSummary)
+	if testSweep != nil {
+		if err = writeSweepTable(testSweep, sweepResults); err != nil {
+			klog.Errorf("Sweep table writing error: %v", err)
+		}
+	}
 
+	exportPrometheusData(prometheusController, prometheusFramework, testsStart, time.Now())
 	if clusterLoaderConfig.EnablePrometheusServer && clusterLoaderConfig.TearDownPrometheusServer {
 		if err := prometheusController.TearDownPrometheusStack(); err != nil {
 			klog.Errorf("Error while tearing down prometheus stack: %v", err)
@@ -0,0 +1,178 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	// SSHActuator stops docker and kubelet over SSH and reboots the node to repair it.
+	SSHActuator = "ssh"
+	// CloudActuator stops and starts the node's instance with the cloud provider CLI.
+	CloudActuator = "cloud"
+	// KubemarkActuator deletes hollow node pod in the kubemark root cluster.
+	KubemarkActuator = "kubemark"
+	// TaintActuator emulates node failure with NoExecute taint evicting node's pods.
+	TaintActuator = "taint"
+
+	kubemarkNamespace   = "kubemark"
+	simulatedFailureKey = "clusterloader.k8s.io/simulated-failure"
+)
+
+// NodeFailureActuator simulates failures of the nodes.
+type NodeFailureActuator interface {
+	// Fail makes the node fail.
+	Fail(node *v1.Node) error
+	// Repair repairs the failed node.
+	Repair(node *v1.Node) error
+}
+
+// DefaultNodeFailureActuator returns actuator used for the provider by default.
+func DefaultNodeFailureActuator(provider string) string {
+	switch provider {
+	case "gce", "gke":
+		return SSHActuator
+	case "kubemark":
+		return KubemarkActuator
+	default:
+		return TaintActuator
+	}
+}
+
+// sshActuator stops docker and kubelet to simulate failure and reboots the node to repair it.
+type sshActuator struct{}
+
+func (*sshActuator) Fail(node *v1.Node) error {
+	return util.SSH("sudo systemctl stop docker kubelet", node, nil)
+}
+
+func (*sshActuator) Repair(node *v1.Node) error {
+	return util.SSH("sudo reboot", node, nil)
+}
+
+// cloudActuator stops and starts node's instance using the cloud provider CLI (gcloud or aws).
+// Instance is identified by the node's provider ID.
+type cloudActuator struct{}
+
+func (a *cloudActuator) Fail(node *v1.Node) error {
+	return a.run(node, "stop")
+}
+
+func (a *cloudActuator) Repair(node *v1.Node) error {
+	return a.run(node, "start")
+}
+
+func (a *cloudActuator) run(node *v1.Node, action string) error {
+	args, err := cloudCommand(node.Spec.ProviderID, action)
+	if err != nil {
+		return err
+	}
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	klog.Infof("%s of %q instance finished with %q: %v", action, node.Name, string(output), err)
+	return err
+}
+
+// cloudCommand returns command performing the action (stop or start) on the instance with the given provider ID.
+func cloudCommand(providerID, action string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(providerID, "gce://"):
+		// gce://<project>/<zone>/<instance>
+		parts := strings.Split(strings.TrimPrefix(providerID, "gce://"), "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid gce provider id %q", providerID)
+		}
+		return []string{"gcloud", "compute", "instances", action, "--project", parts[0], "--zone", parts[1], parts[2]}, nil
+	case strings.HasPrefix(providerID, "aws://"):
+		// aws:///<zone>/<instance>
+		parts := strings.Split(strings.TrimPrefix(providerID, "aws://"), "/")
+		cmd := []string{"aws", "ec2", action + "-instances", "--instance-ids", parts[len(parts)-1]}
+		if len(parts) >= 2 && len(parts[len(parts)-2]) > 1 {
+			// Region is the zone without the trailing letter, e.g. us-east-1 for us-east-1a.
+			zone := parts[len(parts)-2]
+			cmd = append(cmd, "--region", zone[:len(zone)-1])
+		}
+		return cmd, nil
+	default:
+		return nil, fmt.Errorf("provider id %q is not supported by cloud actuator", providerID)
+	}
+}
+
+// kubemarkActuator deletes hollow node pod (named as the node) in the kubemark root cluster.
+// Replication controller recreates the pod, which registers as a new node,
+// so there is nothing to repair.
+type kubemarkActuator struct {
+	rootClient clientset.Interface
+}
+
+func (a *kubemarkActuator) Fail(node *v1.Node) error {
+	return a.rootClient.CoreV1().Pods(kubemarkNamespace).Delete(node.Name, &metav1.DeleteOptions{})
+}
+
+func (a *kubemarkActuator) Repair(node *v1.Node) error {
+	return nil
+}
+
+// taintActuator emulates node failure with NoExecute taint, which evicts all pods
+// not tolerating it (similarly to node lifecycle controller evicting pods from unreachable nodes).
+type taintActuator struct {
+	client clientset.Interface
+}
+
+func (a *taintActuator) Fail(node *v1.Node) error {
+	return a.updateTaints(node.Name, func(taints []v1.Taint) []v1.Taint {
+		for _, taint := range taints {
+			if taint.Key == simulatedFailureKey {
+				return taints
+			}
+		}
+		return append(taints, v1.Taint{Key: simulatedFailureKey, Effect: v1.TaintEffectNoExecute})
+	})
+}
+
+func (a *taintActuator) Repair(node *v1.Node) error {
+	return a.updateTaints(node.Name, func(taints []v1.Taint) []v1.Taint {
+		result := taints[:0]
+		for _, taint := range taints {
+			if taint.Key != simulatedFailureKey {
+				result = append(result, taint)
+			}
+		}
+		return result
+	})
+}
+
+func (a *taintActuator) updateTaints(nodeName string, update func([]v1.Taint) []v1.Taint) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := a.client.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		node.Spec.Taints = update(node.Spec.Taints)
+		_, err = a.client.CoreV1().Nodes().Update(node)
+		return err
+	})
+}
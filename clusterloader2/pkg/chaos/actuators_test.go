@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
+)
+
+// fakeClientset implements listing, deletion, eviction and patching of pods only,
+// calling any other method panics. Pods are listed by namespace, selectors are ignored.
+type fakeClientset struct {
+	clientset.Interface
+	core *fakeCoreV1
+}
+
+func (f *fakeClientset) CoreV1() corev1client.CoreV1Interface {
+	return f.core
+}
+
+type fakeCoreV1 struct {
+	corev1client.CoreV1Interface
+	pods        []v1.Pod
+	deletedPods []string
+	evictedPods []string
+	patches     []string
+}
+
+func (f *fakeCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return &fakePods{namespace: namespace, core: f}
+}
+
+type fakePods struct {
+	corev1client.PodInterface
+	namespace string
+	core      *fakeCoreV1
+}
+
+func (f *fakePods) List(_ metav1.ListOptions) (*v1.PodList, error) {
+	podList := &v1.PodList{}
+	for _, pod := range f.core.pods {
+		if f.namespace == metav1.NamespaceAll || pod.Namespace == f.namespace {
+			podList.Items = append(podList.Items, pod)
+		}
+	}
+	return podList, nil
+}
+
+func (f *fakePods) Delete(name string, _ *metav1.DeleteOptions) error {
+	f.core.deletedPods = append(f.core.deletedPods, f.namespace+"/"+name)
+	return nil
+}
+
+func (f *fakePods) Evict(eviction *policyv1beta1.Eviction) error {
+	f.core.evictedPods = append(f.core.evictedPods, eviction.Namespace+"/"+eviction.Name)
+	return nil
+}
+
+func (f *fakePods) Patch(name string, _ types.PatchType, data []byte, _ ...string) (*v1.Pod, error) {
+	f.core.patches = append(f.core.patches, f.namespace+"/"+name+" "+string(data))
+	return &v1.Pod{}, nil
+}
+
+func TestKubemarkActuator(t *testing.T) {
+	client := &fakeClientset{core: &fakeCoreV1{}}
+	actuator := &kubemarkActuator{rootClient: client}
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "hollow-node-abcde"}}
+
+	assert.NoError(t, actuator.Fail(node))
+	assert.NoError(t, actuator.Repair(node))
+	assert.Equal(t, []string{"kubemark/hollow-node-abcde"}, client.core.deletedPods)
+}
+
+func TestNewNodeFailureActuator(t *testing.T) {
+	cases := []struct {
+		actuator string
+		provider string
+		want     NodeFailureActuator
+		wantErr  bool
+	}{
+		{provider: "gce", want: &sshActuator{}},
+		{provider: "kind", want: &taintActuator{}},
+		{actuator: CloudActuator, provider: "aws", want: &cloudActuator{}},
+		{actuator: SSHActuator, provider: "kind", wantErr: true},
+		{actuator: KubemarkActuator, provider: "gce", wantErr: true},
+		{actuator: "unknown", provider: "gce", wantErr: true},
+	}
+	for _, c := range cases {
+		actuator, err := newNodeFailureActuator(c.actuator, nil, &clconfig.ClusterConfig{Provider: c.provider})
+		if c.wantErr {
+			assert.Error(t, err, "%s on %s", c.actuator, c.provider)
+			continue
+		}
+		assert.NoError(t, err, "%s on %s", c.actuator, c.provider)
+		assert.IsType(t, c.want, actuator, "%s on %s", c.actuator, c.provider)
+	}
+}
+
+func TestCloudCommand(t *testing.T) {
+	cmd, err := cloudCommand("gce://my-project/us-central1-b/node-1", "stop")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gcloud", "compute", "instances", "stop", "--project", "my-project", "--zone", "us-central1-b", "node-1"}, cmd)
+
+	cmd, err = cloudCommand("aws:///us-east-1a/i-0123", "start")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aws", "ec2", "start-instances", "--instance-ids", "i-0123", "--region", "us-east-1"}, cmd)
+
+	cmd, err = cloudCommand("aws://i-0123", "stop")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aws", "ec2", "stop-instances", "--instance-ids", "i-0123"}, cmd)
+
+	_, err = cloudCommand("kind://docker/kind/kind-worker", "stop")
+	assert.Error(t, err)
+}
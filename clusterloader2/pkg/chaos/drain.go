@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/api"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// NodeDrainer is a utility to simulate node drains (e.g. during upgrades).
+type NodeDrainer struct {
+	config   api.NodeDrainConfig
+	client   clientset.Interface
+	recorder *EventRecorder
+}
+
+// NewNodeDrainer creates new NodeDrainer.
+func NewNodeDrainer(config api.NodeDrainConfig, client clientset.Interface, recorder *EventRecorder) (*NodeDrainer, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("node drain interval has to be positive")
+	}
+	return &NodeDrainer{config, client, recorder}, nil
+}
+
+// Run starts NodeDrainer until stopCh is closed.
+// Drained nodes are uncordoned after drain duration or when stopCh is closed.
+func (d *NodeDrainer) Run(stopCh <-chan struct{}) {
+	runPeriodically(func() {
+		// Cordoned nodes are unschedulable, so they won't be picked again.
+		nodes, err := util.GetSchedulableUntainedNodes(d.client)
+		if err != nil {
+			klog.Errorf("%s: Unable to pick nodes to drain: %v", d, err)
+			return
+		}
+		var picked []v1.Node
+		for _, i := range pickRandomIndices(len(nodes), d.config.FailureRate) {
+			picked = append(picked, nodes[i])
+		}
+		d.drain(picked, stopCh)
+	}, d.config.Interval, d.config.JitterFactor, stopCh)
+}
+
+func (d *NodeDrainer) drain(nodes []v1.Node, stopCh <-chan struct{}) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(nodes))
+	for _, node := range nodes {
+		node := node
+		go func() {
+			defer wg.Done()
+
+			klog.Infof("%s: Cordoning %q to simulate drain", d, node.Name)
+			if err := d.setUnschedulable(node.Name, true); err != nil {
+				klog.Errorf("%s: Error while cordoning node %q: %v", d, node.Name, err)
+				return
+			}
+			event := d.recorder.Start("NodeDrain", node.Name)
+			defer d.recorder.Finish(event)
+			if !d.config.CordonOnly {
+				d.evictPods(node.Name)
+			}
+
+			select {
+			case <-time.After(time.Duration(d.config.DrainDuration)):
+			case <-stopCh:
+			}
+
+			klog.Infof("%s: Uncordoning %q", d, node.Name)
+			if err := d.setUnschedulable(node.Name, false); err != nil {
+				klog.Errorf("%s: Error while uncordoning node %q: %v", d, node.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *NodeDrainer) setUnschedulable(nodeName string, unschedulable bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	_, err := d.client.CoreV1().Nodes().Patch(nodeName, types.StrategicMergePatchType, patch)
+	return err
+}
+
+// evictPods evicts pods running on the node, except for mirror and DaemonSet pods.
+// Evictions blocked by PodDisruptionBudgets are not retried.
+func (d *NodeDrainer) evictPods(nodeName string) {
+	selector := fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+	podList, err := d.client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		klog.Errorf("%s: Error while listing pods of node %q: %v", d, nodeName, err)
+		return
+	}
+	for _, pod := range podList.Items {
+		if isMirrorPod(&pod) || isDaemonSetPod(&pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		eviction := &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+		if err := d.client.CoreV1().Pods(pod.Namespace).Evict(eviction); err != nil {
+			klog.Errorf("%s: Error while evicting pod %s/%s: %v", d, pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+func isMirrorPod(pod *v1.Pod) bool {
+	_, ok := pod.Annotations[mirrorPodAnnotation]
+	return ok
+}
+
+func isDaemonSetPod(pod *v1.Pod) bool {
+	controllerRef := metav1.GetControllerOf(pod)
+	return controllerRef != nil && controllerRef.Kind == "DaemonSet"
+}
+
+func (d *NodeDrainer) String() string {
+	return "NodeDrainer"
+}
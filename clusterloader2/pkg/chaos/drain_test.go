@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvictPods(t *testing.T) {
+	now := metav1.Now()
+	isController := true
+	client := &fakeClientset{core: &fakeCoreV1{pods: []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "regular"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "static", Annotations: map[string]string{mirrorPodAnnotation: "hash"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "daemon", OwnerReferences: []metav1.OwnerReference{
+			{Kind: "DaemonSet", Name: "fluentd", Controller: &isController},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "replica", OwnerReferences: []metav1.OwnerReference{
+			{Kind: "ReplicaSet", Name: "app", Controller: &isController},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "terminating", DeletionTimestamp: &now}},
+	}}}
+	drainer := &NodeDrainer{client: client}
+
+	drainer.evictPods("node-1")
+
+	assert.Equal(t, []string{"default/regular", "default/replica"}, client.core.evictedPods)
+	assert.Empty(t, client.core.deletedPods)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event represents a single simulated failure.
+type Event struct {
+	// Type is the type of the failure, e.g. NodeFailure.
+	Type string `json:"type"`
+	// Targets are the names of the affected objects.
+	Targets []string `json:"targets"`
+	// Start is the time when the failure was injected.
+	Start time.Time `json:"start"`
+	// End is the time when the failure was repaired, nil if it hasn't been repaired yet.
+	// For instant failures (e.g. pod deletion) it is equal to Start.
+	End *time.Time `json:"end,omitempty"`
+}
+
+// Overlaps checks whether the event overlaps with the [start, end] interval.
+func (e *Event) Overlaps(start, end time.Time) bool {
+	if e.Start.After(end) {
+		return false
+	}
+	return e.End == nil || !e.End.Before(start)
+}
+
+// EventRecorder records simulated failures.
+type EventRecorder struct {
+	lock   sync.Mutex
+	events []*Event
+}
+
+// NewEventRecorder creates new EventRecorder.
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{}
+}
+
+// Start records start of the failure. Returned event should be passed to Finish once failure is repaired.
+func (r *EventRecorder) Start(eventType string, targets ...string) *Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	event := &Event{Type: eventType, Targets: targets, Start: time.Now()}
+	r.events = append(r.events, event)
+	return event
+}
+
+// Finish records end of the failure.
+func (r *EventRecorder) Finish(event *Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	end := time.Now()
+	event.End = &end
+}
+
+// RecordInstant records failure which is finished immediately.
+func (r *EventRecorder) RecordInstant(eventType string, targets ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	r.events = append(r.events, &Event{Type: eventType, Targets: targets, Start: now, End: &now})
+}
+
+// Events returns copy of the recorded events sorted by start time.
+func (r *EventRecorder) Events() []Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	events := make([]Event, 0, len(r.events))
+	for _, event := range r.events {
+		events = append(events, *event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events
+}
+
+// Overlapping returns events, which overlap with the [start, end] interval.
+func (r *EventRecorder) Overlapping(start, end time.Time) []Event {
+	var result []Event
+	for _, event := range r.Events() {
+		if event.Overlaps(start, end) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
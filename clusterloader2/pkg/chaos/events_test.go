@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventOverlaps(t *testing.T) {
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+	end := func(seconds int) *time.Time {
+		t := at(seconds)
+		return &t
+	}
+	cases := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{name: "before interval", event: Event{Start: at(0), End: end(5)}, want: false},
+		{name: "ends at interval start", event: Event{Start: at(0), End: end(10)}, want: true},
+		{name: "overlaps interval start", event: Event{Start: at(5), End: end(15)}, want: true},
+		{name: "inside interval", event: Event{Start: at(12), End: end(15)}, want: true},
+		{name: "covers interval", event: Event{Start: at(5), End: end(25)}, want: true},
+		{name: "starts at interval end", event: Event{Start: at(20), End: end(25)}, want: true},
+		{name: "after interval", event: Event{Start: at(21), End: end(25)}, want: false},
+		{name: "instant inside interval", event: Event{Start: at(15), End: end(15)}, want: true},
+		{name: "not finished before interval", event: Event{Start: at(0)}, want: true},
+		{name: "not finished after interval", event: Event{Start: at(21)}, want: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, c.event.Overlaps(at(10), at(20)))
+		})
+	}
+}
@@ -17,27 +17,33 @@ limitations under the License.
 package chaos
 
 import (
+	"sync"
+
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/perf-tests/clusterloader2/api"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
 )
 
 // Monkey simulates kubernetes component failures
 type Monkey struct {
-	client     clientset.Interface
-	provider   string
-	nodeKiller *NodeKiller
+	client        clientset.Interface
+	clusterConfig *clconfig.ClusterConfig
+	nodeKiller    *NodeKiller
+	recorder      *EventRecorder
+	// runnersWg tracks runners, which clean up after themselves (e.g. uncordon nodes) once stopped.
+	runnersWg sync.WaitGroup
 }
 
 // NewMonkey constructs a new Monkey object.
-func NewMonkey(client clientset.Interface, provider string) *Monkey {
-	return &Monkey{client: client, provider: provider}
+func NewMonkey(client clientset.Interface, clusterConfig *clconfig.ClusterConfig) *Monkey {
+	return &Monkey{client: client, clusterConfig: clusterConfig, recorder: NewEventRecorder()}
 }
 
 // Init initializes Monkey with given config.
 // When stopCh is closed, the Monkey will stop simulating failures.
 func (m *Monkey) Init(config api.ChaosMonkeyConfig, stopCh <-chan struct{}) error {
 	if config.NodeFailure != nil {
-		nodeKiller, err := NewNodeKiller(*config.NodeFailure, m.client, m.provider)
+		nodeKiller, err := NewNodeKiller(*config.NodeFailure, m.client, m.clusterConfig, m.recorder)
 		if err != nil {
 			return err
 		}
@@ -45,5 +51,59 @@ func (m *Monkey) Init(config api.ChaosMonkeyConfig, stopCh <-chan struct{}) erro
 		go m.nodeKiller.Run(stopCh)
 	}
 
+	var runners []runner
+	if config.PodFailure != nil {
+		podKiller, err := NewPodKiller(*config.PodFailure, m.client, m.recorder)
+		if err != nil {
+			return err
+		}
+		runners = append(runners, podKiller)
+	}
+	if config.ControlPlaneFailure != nil {
+		controlPlaneKiller, err := NewControlPlaneKiller(*config.ControlPlaneFailure, m.client, m.recorder)
+		if err != nil {
+			return err
+		}
+		runners = append(runners, controlPlaneKiller)
+	}
+	if config.NodeDrain != nil {
+		nodeDrainer, err := NewNodeDrainer(*config.NodeDrain, m.client, m.recorder)
+		if err != nil {
+			return err
+		}
+		runners = append(runners, nodeDrainer)
+	}
+	if config.NetworkPartition != nil {
+		networkPartitioner, err := NewNetworkPartitioner(*config.NetworkPartition, m.client, m.recorder)
+		if err != nil {
+			return err
+		}
+		runners = append(runners, networkPartitioner)
+	}
+	for _, r := range runners {
+		r := r
+		m.runnersWg.Add(1)
+		go func() {
+			defer m.runnersWg.Done()
+			r.Run(stopCh)
+		}()
+	}
+
 	return nil
 }
+
+// GetEventRecorder returns recorder of the simulated failures.
+func (m *Monkey) GetEventRecorder() *EventRecorder {
+	return m.recorder
+}
+
+// Wait waits until simulated failures (other than node failures) are stopped and repaired.
+// It should be called after closing stopCh passed to Init.
+func (m *Monkey) Wait() {
+	m.runnersWg.Wait()
+}
+
+// runner simulates failures until stopCh is closed.
+type runner interface {
+	Run(stopCh <-chan struct{})
+}
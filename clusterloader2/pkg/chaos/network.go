@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+const partitionLabel = "clusterloader-chaos-partition"
+
+// NetworkPartitioner is a utility to simulate network partitions.
+// Random pods are labeled and isolated with deny-all NetworkPolicy selecting the label.
+type NetworkPartitioner struct {
+	config     api.NetworkPartitionConfig
+	client     clientset.Interface
+	partitions int
+	recorder   *EventRecorder
+}
+
+// NewNetworkPartitioner creates new NetworkPartitioner.
+func NewNetworkPartitioner(config api.NetworkPartitionConfig, client clientset.Interface, recorder *EventRecorder) (*NetworkPartitioner, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("network partition interval has to be positive")
+	}
+	if config.Namespace == "" {
+		return nil, fmt.Errorf("network partition namespace has to be specified")
+	}
+	return &NetworkPartitioner{config: config, client: client, recorder: recorder}, nil
+}
+
+// Run starts NetworkPartitioner until stopCh is closed.
+// Partitions are healed after partition duration or when stopCh is closed.
+func (p *NetworkPartitioner) Run(stopCh <-chan struct{}) {
+	runPeriodically(func() {
+		pods, err := p.pickPods()
+		if err != nil {
+			klog.Errorf("%s: Unable to pick pods to partition: %v", p, err)
+			return
+		}
+		if len(pods) == 0 {
+			return
+		}
+		p.partitions++
+		p.partition(fmt.Sprintf("%s-%d", partitionLabel, p.partitions), pods, stopCh)
+	}, p.config.Interval, p.config.JitterFactor, stopCh)
+}
+
+func (p *NetworkPartitioner) pickPods() ([]v1.Pod, error) {
+	podList, err := p.client.CoreV1().Pods(p.config.Namespace).List(metav1.ListOptions{LabelSelector: p.config.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	pods := podList.Items[:0]
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	var picked []v1.Pod
+	for _, i := range pickRandomIndices(len(pods), p.config.FailureRate) {
+		picked = append(picked, pods[i])
+	}
+	return picked, nil
+}
+
+func (p *NetworkPartitioner) partition(name string, pods []v1.Pod, stopCh <-chan struct{}) {
+	klog.Infof("%s: Isolating %d pods in %s with %s network policy", p, len(pods), p.config.Namespace, name)
+	var labeled []v1.Pod
+	for _, pod := range pods {
+		if err := p.setPartitionLabel(pod.Name, fmt.Sprintf("%q", name)); err != nil {
+			klog.Errorf("%s: Error while labeling pod %s/%s: %v", p, pod.Namespace, pod.Name, err)
+			continue
+		}
+		labeled = append(labeled, pod)
+	}
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{partitionLabel: name}},
+			// No ingress and egress rules mean that all traffic is denied.
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+	policyCreated := true
+	if _, err := p.client.NetworkingV1().NetworkPolicies(p.config.Namespace).Create(policy); err != nil {
+		klog.Errorf("%s: Error while creating network policy %s: %v", p, name, err)
+		policyCreated = false
+	}
+
+	if policyCreated {
+		var targets []string
+		for _, pod := range labeled {
+			targets = append(targets, pod.Namespace+"/"+pod.Name)
+		}
+		event := p.recorder.Start("NetworkPartition", targets...)
+		select {
+		case <-time.After(time.Duration(p.config.PartitionDuration)):
+		case <-stopCh:
+		}
+		klog.Infof("%s: Healing %s partition", p, name)
+		if err := p.client.NetworkingV1().NetworkPolicies(p.config.Namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("%s: Error while deleting network policy %s: %v", p, name, err)
+		}
+		p.recorder.Finish(event)
+	}
+	for _, pod := range labeled {
+		if err := p.setPartitionLabel(pod.Name, "null"); err != nil {
+			klog.Errorf("%s: Error while unlabeling pod %s/%s: %v", p, pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+// setPartitionLabel sets partition label of the pod to the given json value (null removes the label).
+func (p *NetworkPartitioner) setPartitionLabel(podName, value string) error {
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%s}}}`, partitionLabel, value))
+	_, err := p.client.CoreV1().Pods(p.config.Namespace).Patch(podName, types.MergePatchType, patch)
+	return err
+}
+
+func (p *NetworkPartitioner) String() string {
+	return "NetworkPartitioner"
+}
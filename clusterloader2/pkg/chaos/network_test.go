@@ -0,0 +1,39 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+func TestSetPartitionLabel(t *testing.T) {
+	client := &fakeClientset{core: &fakeCoreV1{}}
+	partitioner := &NetworkPartitioner{config: api.NetworkPartitionConfig{Namespace: "test"}, client: client}
+	name := partitionLabel + "-1"
+
+	assert.NoError(t, partitioner.setPartitionLabel("pod-1", fmt.Sprintf("%q", name)))
+	assert.NoError(t, partitioner.setPartitionLabel("pod-1", "null"))
+
+	assert.Equal(t, []string{
+		`test/pod-1 {"metadata":{"labels":{"clusterloader-chaos-partition":"clusterloader-chaos-partition-1"}}}`,
+		`test/pod-1 {"metadata":{"labels":{"clusterloader-chaos-partition":null}}}`,
+	}, client.core.patches)
+}
@@ -23,11 +23,12 @@ import (
 	"time"
 
 	"k8s.io/perf-tests/clusterloader2/api"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 )
@@ -36,31 +37,60 @@ import (
 type NodeKiller struct {
 	config   api.NodeFailureConfig
 	client   clientset.Interface
-	provider string
+	actuator NodeFailureActuator
 	// killedNodes stores names of the nodes that have been killed by NodeKiller.
 	killedNodes sets.String
+	recorder    *EventRecorder
 }
 
 // NewNodeKiller creates new NodeKiller.
-func NewNodeKiller(config api.NodeFailureConfig, client clientset.Interface, provider string) (*NodeKiller, error) {
-	if provider != "gce" && provider != "gke" {
-		return nil, fmt.Errorf("provider %q is not supported by NodeKiller")
+func NewNodeKiller(config api.NodeFailureConfig, client clientset.Interface, clusterConfig *clconfig.ClusterConfig, recorder *EventRecorder) (*NodeKiller, error) {
+	actuator, err := newNodeFailureActuator(config.Actuator, client, clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeKiller{config, client, actuator, sets.NewString(), recorder}, nil
+}
+
+func newNodeFailureActuator(actuator string, client clientset.Interface, clusterConfig *clconfig.ClusterConfig) (NodeFailureActuator, error) {
+	if actuator == "" {
+		actuator = DefaultNodeFailureActuator(clusterConfig.Provider)
+	}
+	switch actuator {
+	case SSHActuator:
+		// SSH is done with gcloud.
+		if clusterConfig.Provider != "gce" && clusterConfig.Provider != "gke" {
+			return nil, fmt.Errorf("provider %q is not supported by %s actuator", clusterConfig.Provider, actuator)
+		}
+		return &sshActuator{}, nil
+	case CloudActuator:
+		return &cloudActuator{}, nil
+	case KubemarkActuator:
+		if clusterConfig.Provider != "kubemark" {
+			return nil, fmt.Errorf("provider %q is not supported by %s actuator", clusterConfig.Provider, actuator)
+		}
+		rootFramework, err := framework.NewRootFramework(clusterConfig, 1)
+		if err != nil {
+			return nil, fmt.Errorf("kubemark root framework creation error: %v", err)
+		}
+		return &kubemarkActuator{rootClient: rootFramework.GetClientSets().GetClient()}, nil
+	case TaintActuator:
+		return &taintActuator{client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown node failure actuator %q", actuator)
 	}
-	return &NodeKiller{config, client, provider, sets.NewString()}, nil
 }
 
 // Run starts NodeKiller until stopCh is closed.
 func (k *NodeKiller) Run(stopCh <-chan struct{}) {
-	// wait.JitterUntil starts work immediately, so wait first.
-	time.Sleep(wait.Jitter(time.Duration(k.config.Interval), k.config.JitterFactor))
-	wait.JitterUntil(func() {
+	runPeriodically(func() {
 		nodes, err := k.pickNodes()
 		if err != nil {
 			klog.Errorf("%s: Unable to pick nodes to kill: %v", k, err)
 			return
 		}
 		k.kill(nodes)
-	}, time.Duration(k.config.Interval), k.config.JitterFactor, true, stopCh)
+	}, k.config.Interval, k.config.JitterFactor, stopCh)
 }
 
 func (k *NodeKiller) pickNodes() ([]v1.Node, error) {
@@ -93,19 +123,22 @@ func (k *NodeKiller) kill(nodes []v1.Node) {
 		go func() {
 			defer wg.Done()
 
-			klog.Infof("%s: Stopping docker and kubelet on %q to simulate failure", k, node.Name)
-			err := util.SSH("sudo systemctl stop docker kubelet", &node, nil)
+			klog.Infof("%s: Simulating failure of %q", k, node.Name)
+			err := k.actuator.Fail(&node)
 			if err != nil {
 				klog.Errorf("%s: ERROR while stopping node %q: %v", k, node.Name, err)
 				return
 			}
+			// Failure is recorded only once it has been injected.
+			event := k.recorder.Start("NodeFailure", node.Name)
+			defer k.recorder.Finish(event)
 
 			time.Sleep(time.Duration(k.config.SimulatedDowntime))
 
-			klog.Infof("%s: Rebooting %q to repair the node", k, node.Name)
-			err = util.SSH("sudo reboot", &node, nil)
+			klog.Infof("%s: Repairing %q", k, node.Name)
+			err = k.actuator.Repair(&node)
 			if err != nil {
-				klog.Errorf("%s: Error while rebooting node %q: %v", k, node.Name, err)
+				klog.Errorf("%s: Error while repairing node %q: %v", k, node.Name, err)
 				return
 			}
 		}()
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+// fakeNodeActuator fails nodes listed in failing and records the repaired ones.
+type fakeNodeActuator struct {
+	failing  sets.String
+	repaired []string
+}
+
+func (a *fakeNodeActuator) Fail(node *v1.Node) error {
+	if a.failing.Has(node.Name) {
+		return fmt.Errorf("failing %s error", node.Name)
+	}
+	return nil
+}
+
+func (a *fakeNodeActuator) Repair(node *v1.Node) error {
+	a.repaired = append(a.repaired, node.Name)
+	return nil
+}
+
+func TestNodeKillerKill(t *testing.T) {
+	actuator := &fakeNodeActuator{failing: sets.NewString("node-2")}
+	recorder := NewEventRecorder()
+	k := &NodeKiller{
+		config:      api.NodeFailureConfig{},
+		actuator:    actuator,
+		killedNodes: sets.NewString(),
+		recorder:    recorder,
+	}
+
+	k.kill([]v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+	})
+
+	events := recorder.Events()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "NodeFailure", events[0].Type)
+		assert.Equal(t, []string{"node-1"}, events[0].Targets)
+		assert.NotNil(t, events[0].End)
+	}
+	assert.Equal(t, []string{"node-1"}, actuator.repaired)
+	assert.Equal(t, sets.NewString("node-1", "node-2"), k.killedNodes)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+var defaultControlPlaneComponents = []string{"kube-scheduler", "kube-controller-manager"}
+
+// PodKiller is a utility to simulate pod failures by deleting random pods.
+type PodKiller struct {
+	name          string
+	eventType     string
+	client        clientset.Interface
+	namespace     string
+	labelSelector string
+	failureRate   float64
+	interval      api.Duration
+	jitterFactor  float64
+	recorder      *EventRecorder
+}
+
+// NewPodKiller creates new PodKiller deleting pods selected by the config.
+func NewPodKiller(config api.PodFailureConfig, client clientset.Interface, recorder *EventRecorder) (*PodKiller, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("pod failure interval has to be positive")
+	}
+	return &PodKiller{
+		name:          "PodKiller",
+		eventType:     "PodFailure",
+		client:        client,
+		namespace:     config.Namespace,
+		labelSelector: config.LabelSelector,
+		failureRate:   config.FailureRate,
+		interval:      config.Interval,
+		jitterFactor:  config.JitterFactor,
+		recorder:      recorder,
+	}, nil
+}
+
+// NewControlPlaneKiller creates new PodKiller deleting pods of the control plane components.
+// Components are selected in kube-system namespace by the component label.
+// Deleting mirror pod doesn't restart the static pod, so only components running
+// as regular pods (e.g. in self-hosted control plane) can be restarted. An error is returned
+// if all of the selected pods are mirror pods.
+func NewControlPlaneKiller(config api.ControlPlaneFailureConfig, client clientset.Interface, recorder *EventRecorder) (*PodKiller, error) {
+	if config.Interval <= 0 {
+		return nil, fmt.Errorf("control plane failure interval has to be positive")
+	}
+	components := config.Components
+	if len(components) == 0 {
+		components = defaultControlPlaneComponents
+	}
+	k := &PodKiller{
+		name:          "ControlPlaneKiller",
+		eventType:     "ControlPlaneFailure",
+		client:        client,
+		namespace:     metav1.NamespaceSystem,
+		labelSelector: fmt.Sprintf("component in (%s)", strings.Join(components, ",")),
+		failureRate:   config.FailureRate,
+		interval:      config.Interval,
+		jitterFactor:  config.JitterFactor,
+		recorder:      recorder,
+	}
+	podList, err := client.CoreV1().Pods(k.namespace).List(metav1.ListOptions{LabelSelector: k.labelSelector})
+	if err != nil {
+		klog.Warningf("%s: Unable to list control plane pods: %v", k, err)
+		return k, nil
+	}
+	if len(podList.Items) == 0 {
+		klog.Warningf("%s: No pods of %v components found", k, components)
+		return k, nil
+	}
+	for i := range podList.Items {
+		if !isMirrorPod(&podList.Items[i]) {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("all pods of %v components are mirror pods, deleting them doesn't restart the components", components)
+}
+
+// Run starts PodKiller until stopCh is closed.
+func (k *PodKiller) Run(stopCh <-chan struct{}) {
+	runPeriodically(func() {
+		pods, err := k.pickPods()
+		if err != nil {
+			klog.Errorf("%s: Unable to pick pods to kill: %v", k, err)
+			return
+		}
+		k.kill(pods)
+	}, k.interval, k.jitterFactor, stopCh)
+}
+
+func (k *PodKiller) pickPods() ([]v1.Pod, error) {
+	podList, err := k.client.CoreV1().Pods(k.namespace).List(metav1.ListOptions{LabelSelector: k.labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	pods := podList.Items[:0]
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	var picked []v1.Pod
+	for _, i := range pickRandomIndices(len(pods), k.failureRate) {
+		picked = append(picked, pods[i])
+	}
+	return picked, nil
+}
+
+func (k *PodKiller) kill(pods []v1.Pod) {
+	for _, pod := range pods {
+		klog.Infof("%s: Deleting pod %s/%s to simulate failure", k, pod.Namespace, pod.Name)
+		if err := k.client.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("%s: Error while deleting pod %s/%s: %v", k, pod.Namespace, pod.Name, err)
+			continue
+		}
+		k.recorder.RecordInstant(k.eventType, pod.Namespace+"/"+pod.Name)
+	}
+}
+
+func (k *PodKiller) String() string {
+	return k.name
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+func TestNewControlPlaneKiller(t *testing.T) {
+	mirrorPod := v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   metav1.NamespaceSystem,
+		Name:        "kube-scheduler-master",
+		Annotations: map[string]string{mirrorPodAnnotation: "hash"},
+	}}
+	regularPod := v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: metav1.NamespaceSystem,
+		Name:      "kube-controller-manager-abcde",
+	}}
+	config := api.ControlPlaneFailureConfig{FailureRate: 0.5, Interval: api.Duration(time.Minute)}
+	cases := []struct {
+		name    string
+		pods    []v1.Pod
+		wantErr bool
+	}{
+		{name: "regular pods", pods: []v1.Pod{regularPod}},
+		{name: "mixed pods", pods: []v1.Pod{mirrorPod, regularPod}},
+		{name: "no pods", pods: nil},
+		{name: "mirror pods only", pods: []v1.Pod{mirrorPod}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &fakeClientset{core: &fakeCoreV1{pods: c.pods}}
+			_, err := NewControlPlaneKiller(config, client, NewEventRecorder())
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPodKillerPickPods(t *testing.T) {
+	now := metav1.Now()
+	running := v1.PodStatus{Phase: v1.PodRunning}
+	client := &fakeClientset{core: &fakeCoreV1{pods: []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "running-1"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "running-2"}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pending"}, Status: v1.PodStatus{Phase: v1.PodPending}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "terminating", DeletionTimestamp: &now}, Status: running},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "running"}, Status: running},
+	}}}
+	killer := &PodKiller{client: client, namespace: "test", failureRate: 1, recorder: NewEventRecorder()}
+
+	pods, err := killer.pickPods()
+	assert.NoError(t, err)
+	killer.kill(pods)
+
+	assert.ElementsMatch(t, []string{"test/running-1", "test/running-2"}, client.core.deletedPods)
+	assert.Len(t, killer.recorder.Events(), 2)
+}
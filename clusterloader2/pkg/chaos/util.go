@@ -0,0 +1,46 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"math/rand"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/perf-tests/clusterloader2/api"
+)
+
+// runPeriodically runs f every jittered interval until stopCh is closed.
+func runPeriodically(f func(), interval api.Duration, jitterFactor float64, stopCh <-chan struct{}) {
+	// wait.JitterUntil starts work immediately, so wait first.
+	select {
+	case <-time.After(wait.Jitter(time.Duration(interval), jitterFactor)):
+	case <-stopCh:
+		return
+	}
+	wait.JitterUntil(f, time.Duration(interval), jitterFactor, true, stopCh)
+}
+
+// pickRandomIndices returns random failureRate fraction of indices of n elements.
+func pickRandomIndices(n int, failureRate float64) []int {
+	indices := rand.Perm(n)
+	numPicked := int(failureRate * float64(n))
+	if numPicked < len(indices) {
+		return indices[:numPicked]
+	}
+	return indices
+}
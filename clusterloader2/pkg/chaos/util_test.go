@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickRandomIndices(t *testing.T) {
+	cases := []struct {
+		n           int
+		failureRate float64
+		want        int
+	}{
+		{n: 10, failureRate: 0, want: 0},
+		{n: 10, failureRate: 0.3, want: 3},
+		{n: 10, failureRate: 0.25, want: 2},
+		{n: 10, failureRate: 1, want: 10},
+		{n: 10, failureRate: 1.5, want: 10},
+		{n: 0, failureRate: 0.5, want: 0},
+	}
+	for _, c := range cases {
+		indices := pickRandomIndices(c.n, c.failureRate)
+		assert.Len(t, indices, c.want, "n: %d, failureRate: %v", c.n, c.failureRate)
+		seen := make(map[int]bool)
+		for _, i := range indices {
+			assert.True(t, i >= 0 && i < c.n, "index %d out of range [0, %d)", i, c.n)
+			assert.False(t, seen[i], "index %d picked twice", i)
+			seen[i] = true
+		}
+	}
+}
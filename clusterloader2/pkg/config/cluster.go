@@ -16,6 +16,8 @@ limitations under the License.
 
 package config
 
+import "time"
+
 // ClusterLoaderConfig represents all flags used by CLusterLoader
 type ClusterLoaderConfig struct {
 	ClusterConfig            ClusterConfig `json: clusterConfig`
@@ -25,17 +27,50 @@ type ClusterLoaderConfig struct {
 	TearDownPrometheusServer bool          `json: tearDownPrometheusServer`
 	TestConfigPath           string        `json: testConfigPath`
 	TestOverridesPath        []string      `json: testOverrides`
+	// OverrideValues are template variables in "KEY=VALUE" form.
+	OverrideValues []string `json:"overrideValues"`
+	// PrometheusURL is the address of an existing Prometheus server. If set, the prometheus stack
+	// is not set up and measurements query this server instead.
+	PrometheusURL string `json:"prometheusURL"`
+	// PrometheusBearerTokenFile is a file with the bearer token used to authenticate to PrometheusURL.
+	PrometheusBearerTokenFile string `json:"prometheusBearerTokenFile"`
+	// PrometheusExportQueries are queries whose results over the whole run are exported to the report dir.
+	PrometheusExportQueries []string `json:"prometheusExportQueries"`
+	// PrometheusExportStep is the resolution of the exported query results.
+	PrometheusExportStep time.Duration `json:"prometheusExportStep"`
+	// PrometheusExportSnapshot enables exporting TSDB snapshot of the prometheus server set up in the cluster.
+	PrometheusExportSnapshot bool `json:"prometheusExportSnapshot"`
+	// SweepValues are template variables of the current sweep combination.
+	SweepValues map[string]interface{} `json:"sweepValues,omitempty"`
 }
 
 // ClusterConfig is a structure that represents cluster description.
 type ClusterConfig struct {
-	KubeConfigPath             string   `json: kubeConfigPath`
-	Nodes                      int      `json: nodes`
-	Provider                   string   `json: provider`
-	MasterIPs                  []string `json: masterIPs`
-	MasterInternalIPs          []string `json: masterInternalIPs`
-	MasterName                 string   `json: masterName`
-	KubemarkRootKubeConfigPath string   `json: kubemarkRootKubeConfigPath`
+	KubeConfigPath             string       `json: kubeConfigPath`
+	Nodes                      int          `json: nodes`
+	Provider                   string       `json: provider`
+	MasterIPs                  []string     `json: masterIPs`
+	MasterInternalIPs          []string     `json: masterInternalIPs`
+	MasterName                 string       `json: masterName`
+	KubemarkRootKubeConfigPath string       `json: kubemarkRootKubeConfigPath`
+	ClientConfig               ClientConfig `json:"clientConfig"`
+	// MasterAccess is the method of accessing master components (ssh, proxy, exec or http).
+	// If empty, method is chosen based on the provider.
+	MasterAccess string `json:"masterAccess"`
+}
+
+// ClientConfig is a structure that represents configuration of the kubernetes clients.
+type ClientConfig struct {
+	// QPS and Burst configure client-side throttling of every client.
+	// Negative QPS disables throttling.
+	QPS   int `json:"qps"`
+	Burst int `json:"burst"`
+	// ContentType is either "protobuf" or "json".
+	ContentType string `json:"contentType"`
+	// ClientsNumber is the number of clients used by the framework.
+	// If zero, it is derived from the number of nodes.
+	ClientsNumber int    `json:"clientsNumber"`
+	UserAgent     string `json:"userAgent"`
 }
 
 // GetMasterIp returns the first master ip, added for backward compatibility.
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvOverridePrefix is the prefix of env variables injected into the template mapping.
+	// The prefix is stripped, e.g. CL2_NODES_PER_NAMESPACE sets NODES_PER_NAMESPACE variable.
+	EnvOverridePrefix = "CL2_"
+)
+
+// GetEnvMapping returns mapping created from env variables (in "KEY=VALUE" form)
+// prefixed with EnvOverridePrefix.
+func GetEnvMapping(environ []string) map[string]interface{} {
+	mapping := make(map[string]interface{})
+	for _, env := range environ {
+		if !strings.HasPrefix(env, EnvOverridePrefix) {
+			continue
+		}
+		// Env variables always contain "=".
+		kv := strings.SplitN(strings.TrimPrefix(env, EnvOverridePrefix), "=", 2)
+		if kv[0] == "" || len(kv) != 2 {
+			continue
+		}
+		mapping[kv[0]] = ParseOverrideValue(kv[1])
+	}
+	return mapping
+}
+
+// GetSetMapping returns mapping created from the list of "KEY=VALUE" pairs.
+// The latter pairs take precedence over the former ones.
+func GetSetMapping(values []string) (map[string]interface{}, error) {
+	mapping := make(map[string]interface{})
+	for _, value := range values {
+		kv := strings.SplitN(value, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid override %q, expected KEY=VALUE", value)
+		}
+		mapping[kv[0]] = ParseOverrideValue(kv[1])
+	}
+	return mapping, nil
+}
+
+// ParseOverrideValue converts the value to int, float64, bool or time.Duration,
+// trying them in this order. If none of them matches, value is returned as a string.
+// Only "true" and "false" (case insensitive) are treated as bool values.
+func ParseOverrideValue(value string) interface{} {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+		return strings.EqualFold(value, "true")
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOverrideValue(t *testing.T) {
+	for value, want := range map[string]interface{}{
+		"10":       10,
+		"-3":       -3,
+		"0.5":      0.5,
+		"true":     true,
+		"False":    false,
+		"t":        "t",
+		"30s":      30 * time.Second,
+		"1h30m":    90 * time.Minute,
+		"foo":      "foo",
+		"":         "",
+		"a=b":      "a=b",
+		"1,2,3":    "1,2,3",
+		"10 nodes": "10 nodes",
+	} {
+		assert.Equal(t, want, ParseOverrideValue(value), "value %q", value)
+	}
+}
+
+func TestGetMappingPrecedence(t *testing.T) {
+	clusterLoaderConfig := &ClusterLoaderConfig{
+		ClusterConfig:  ClusterConfig{Nodes: 100},
+		OverrideValues: []string{"A=set", "B=1", "B=2", "D=set", "Nodes=1"},
+		SweepValues:    map[string]interface{}{"D": 10},
+	}
+	environ := []string{"CL2_A=env", "CL2_C=true", "C=ignored", "PATH=/bin"}
+	mapping, errList := getMapping(clusterLoaderConfig, environ)
+	if errList != nil {
+		t.Fatalf("unexpected error: %v", errList)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"A":     "set",
+		"B":     2,
+		"C":     true,
+		"D":     10,
+		"Nodes": 100,
+	}, mapping)
+}
+
+func TestGetSetMappingInvalid(t *testing.T) {
+	for _, value := range []string{"A", "=1"} {
+		_, err := GetSetMapping([]string{value})
+		assert.Error(t, err, "value %q", value)
+	}
+}
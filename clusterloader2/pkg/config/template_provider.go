@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"regexp"
 	"sync"
@@ -182,11 +183,32 @@ func GetOverridesMapping(paths []string) (map[string]interface{}, error) {
 }
 
 // GetMapping returns template variable mapping for the given ClusterLoaderConfig.
+// Variables are taken from (in the order of increasing precedence):
+// override files, env variables prefixed with EnvOverridePrefix, override values
+// and values of the current sweep combination.
+// Nodes variable is always set to the number of nodes from the cluster config.
 func GetMapping(clusterLoaderConfig *ClusterLoaderConfig) (map[string]interface{}, *errors.ErrorList) {
+	return getMapping(clusterLoaderConfig, os.Environ())
+}
+
+func getMapping(clusterLoaderConfig *ClusterLoaderConfig, environ []string) (map[string]interface{}, *errors.ErrorList) {
 	mapping, err := GetOverridesMapping(clusterLoaderConfig.TestOverridesPath)
 	if err != nil {
 		return nil, errors.NewErrorList(fmt.Errorf("mapping creation error: %v", err))
 	}
+	for k, v := range GetEnvMapping(environ) {
+		mapping[k] = v
+	}
+	setMapping, err := GetSetMapping(clusterLoaderConfig.OverrideValues)
+	if err != nil {
+		return nil, errors.NewErrorList(fmt.Errorf("mapping creation error: %v", err))
+	}
+	for k, v := range setMapping {
+		mapping[k] = v
+	}
+	for k, v := range clusterLoaderConfig.SweepValues {
+		mapping[k] = v
+	}
 	mapping["Nodes"] = clusterLoaderConfig.ClusterConfig.Nodes
 	return mapping, nil
 }
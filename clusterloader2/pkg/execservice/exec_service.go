@@ -19,13 +19,15 @@ package execservice
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math/rand"
-	"os/exec"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/klog"
 
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
@@ -47,10 +49,23 @@ const (
 )
 
 var (
-	lock     sync.Mutex
-	podStore *measurementutil.PodStore
+	lock       sync.Mutex
+	podStore   *measurementutil.PodStore
+	k8sClient  clientset.Interface
+	restConfig *restclient.Config
 )
 
+// CommandOptions configures execution of the command.
+type CommandOptions struct {
+	// PodName is the name of the exec pod, random exec pod is used if empty.
+	PodName string
+	// Timeout is the command timeout, command isn't limited if zero.
+	Timeout time.Duration
+	// Stdout and Stderr (if set) receive command output as it is produced.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
 // SetUpExecService creates exec pod.
 func SetUpExecService(f *framework.Framework) error {
 	var err error
@@ -60,6 +75,10 @@ func SetUpExecService(f *framework.Framework) error {
 		klog.Infof("%s: service already running!", execServiceName)
 	}
 	klog.Infof("%v: setting up service!", execServiceName)
+	k8sClient = f.GetClientSets().GetClient()
+	if restConfig, err = f.GetRestClientConfig(); err != nil {
+		return fmt.Errorf("rest client config creation error: %v", err)
+	}
 	mapping := make(map[string]interface{})
 	mapping["Name"] = execDeploymentName
 	mapping["Namespace"] = execDeploymentNamespace
@@ -118,20 +137,64 @@ func TearDownExecService(f *framework.Framework) error {
 
 // RunCommand executes given command on a pod in cluster.
 func RunCommand(cmd string) (string, error) {
-	pod, err := getPod()
-	if err != nil {
-		return "", err
+	return RunCommandWithOptions(cmd, &CommandOptions{})
+}
+
+// RunCommandWithOptions executes given command on a pod in cluster.
+// On failure, stderr output is returned instead of stdout output.
+func RunCommandWithOptions(cmd string, options *CommandOptions) (string, error) {
+	podName := options.PodName
+	if podName == "" {
+		pod, err := getPod()
+		if err != nil {
+			return "", err
+		}
+		podName = pod.Name
 	}
 
 	var stdout, stderr bytes.Buffer
-	c := exec.Command("kubectl", "exec", fmt.Sprintf("--namespace=%v", pod.Namespace), pod.Name, "--", "/bin/sh", "-x", "-c", cmd)
-	c.Stdout, c.Stderr = &stdout, &stderr
-	if err := c.Run(); err != nil {
+	var stdoutWriter, stderrWriter io.Writer = &stdout, &stderr
+	if options.Stdout != nil {
+		stdoutWriter = io.MultiWriter(&stdout, options.Stdout)
+	}
+	if options.Stderr != nil {
+		stderrWriter = io.MultiWriter(&stderr, options.Stderr)
+	}
+	command := []string{"/bin/sh", "-x", "-c", cmd}
+
+	// Nil channel (no timeout) is never closed.
+	var stopCh chan struct{}
+	if options.Timeout > 0 {
+		stopCh = make(chan struct{})
+		timer := time.AfterFunc(options.Timeout, func() { close(stopCh) })
+		defer timer.Stop()
+	}
+	// ExecInPod returns once the stream is finished, so buffers are not written afterwards.
+	if err := client.ExecInPod(k8sClient, restConfig, execDeploymentNamespace, podName, "", command, stdoutWriter, stderrWriter, stopCh); err != nil {
+		select {
+		case <-stopCh:
+			return stderr.String(), fmt.Errorf("command timed out after %v: %v", options.Timeout, err)
+		default:
+		}
 		return stderr.String(), err
 	}
 	return stdout.String(), nil
 }
 
+// ListPodNames returns names of the exec pods.
+func ListPodNames() ([]string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if podStore == nil {
+		return nil, fmt.Errorf("exec service not started")
+	}
+	var names []string
+	for _, pod := range podStore.List() {
+		names = append(names, pod.Name)
+	}
+	return names, nil
+}
+
 func getPod() (*corev1.Pod, error) {
 	lock.Lock()
 	defer lock.Unlock()
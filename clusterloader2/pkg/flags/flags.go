@@ -22,6 +22,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"k8s.io/klog"
@@ -50,6 +51,11 @@ func BoolVar(b *bool, flagName string, defaultValue bool, description string) {
 	pflag.BoolVar(b, flagName, defaultValue, description)
 }
 
+// DurationVar creates a duration flag with given parameters.
+func DurationVar(d *time.Duration, flagName string, defaultValue time.Duration, description string) {
+	pflag.DurationVar(d, flagName, defaultValue, description)
+}
+
 // StringEnvVar creates string flag with given parameters.
 // If flag is not provided, it will try to get env variable.
 func StringEnvVar(s *string, flagName, envVariable, defaultValue, description string) {
@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// ExecInPod executes the command in the container of the given pod using the pod exec subresource.
+// Container may be empty if the pod has a single container. Command output is streamed
+// to stdout and stderr. The config is needed to establish the streaming connection.
+// Closing stopCh closes the connection; ExecInPod returns once the stream is finished,
+// so stdout and stderr are not written afterwards. Nil stopCh means no cancellation.
+func ExecInPod(c clientset.Interface, config *restclient.Config, namespace, podName, container string, command []string, stdout, stderr io.Writer, stopCh <-chan struct{}) error {
+	req := c.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&apiv1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil,
+		}, scheme.ParameterCodec)
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return fmt.Errorf("creating round tripper for %s/%s error: %v", namespace, podName, err)
+	}
+	closing := &closingUpgrader{Upgrader: upgrader}
+	executor, err := remotecommand.NewSPDYExecutorForTransports(transport, closing, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("creating executor for %s/%s error: %v", namespace, podName, err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stopCh:
+			closing.close()
+		case <-done:
+		}
+	}()
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// closingUpgrader remembers the upgraded connection, so that it can be closed
+// while the stream is in progress.
+type closingUpgrader struct {
+	spdy.Upgrader
+
+	lock   sync.Mutex
+	conn   httpstream.Connection
+	closed bool
+}
+
+func (u *closingUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.Upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.closed {
+		conn.Close()
+		return nil, fmt.Errorf("exec stopped")
+	}
+	u.conn = conn
+	return conn, nil
+}
+
+func (u *closingUpgrader) close() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.closed = true
+	if u.conn != nil {
+		u.conn.Close()
+	}
+}
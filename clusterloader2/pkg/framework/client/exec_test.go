@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+type fakeConnection struct {
+	httpstream.Connection
+	closed bool
+}
+
+func (c *fakeConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+type fakeUpgrader struct {
+	conn *fakeConnection
+}
+
+func (u *fakeUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	return u.conn, nil
+}
+
+func TestClosingUpgrader(t *testing.T) {
+	conn := &fakeConnection{}
+	u := &closingUpgrader{Upgrader: &fakeUpgrader{conn: conn}}
+	got, err := u.NewConnection(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, conn, got)
+	assert.False(t, conn.closed)
+	u.close()
+	assert.True(t, conn.closed)
+
+	// Connection upgraded after close is closed immediately.
+	conn = &fakeConnection{}
+	u = &closingUpgrader{Upgrader: &fakeUpgrader{conn: conn}}
+	u.close()
+	_, err = u.NewConnection(nil)
+	assert.Error(t, err)
+	assert.True(t, conn.closed)
+}
@@ -46,6 +46,10 @@ const (
 	// Parameters for namespace deletion operations.
 	defaultNamespaceDeletionTimeout  = 10 * time.Minute
 	defaultNamespaceDeletionInterval = 5 * time.Second
+
+	// TimestampAnnotation is the annotation in which the time of the create or patch
+	// api call is recorded. It allows watchers to compute how fast they observe changes.
+	TimestampAnnotation = "clusterloader2.k8s.io/timestamp"
 )
 
 // RetryWithExponentialBackOff a utility for retrying the given function with exponential backoff.
@@ -131,6 +135,30 @@ func RetryFunction(f func() error, options ...*ApiCallOptions) wait.ConditionFun
 	}
 }
 
+// SetTimestampAnnotation sets TimestampAnnotation of the given object to the given time.
+func SetTimestampAnnotation(obj *unstructured.Unstructured, t time.Time) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[TimestampAnnotation] = t.Format(time.RFC3339Nano)
+	obj.SetAnnotations(annotations)
+}
+
+// GetTimestampAnnotation returns time recorded in TimestampAnnotation of the given object.
+// If the annotation is not set, false is returned.
+func GetTimestampAnnotation(obj metav1.Object) (time.Time, bool, error) {
+	value, ok := obj.GetAnnotations()[TimestampAnnotation]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing %s annotation error: %v", TimestampAnnotation, err)
+	}
+	return t, true, nil
+}
+
 // ListNodes returns list of cluster nodes.
 func ListNodes(c clientset.Interface) ([]apiv1.Node, error) {
 	var nodes []apiv1.Node
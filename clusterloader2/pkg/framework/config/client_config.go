@@ -27,26 +27,52 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/transport"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
 )
 
 const (
-	contentType = "application/vnd.kubernetes.protobuf"
-	qps         = 100
-	burst       = 200
+	// DefaultQPS is the default client qps limit.
+	DefaultQPS = 100
+	// DefaultBurst is the default client burst limit.
+	DefaultBurst = 200
+	// ContentTypeProtobuf denotes protobuf serialization of api requests.
+	ContentTypeProtobuf = "protobuf"
+	// ContentTypeJSON denotes json serialization of api requests.
+	ContentTypeJSON = "json"
 )
 
+var contentTypes = map[string]string{
+	ContentTypeProtobuf: "application/vnd.kubernetes.protobuf",
+	ContentTypeJSON:     "application/json",
+}
+
 // PrepareConfig creates and initializes client config.
-func PrepareConfig(path string) (*restclient.Config, error) {
+// Fields of clientConfig that are not set are replaced with defaults.
+func PrepareConfig(path string, clientConfig *clconfig.ClientConfig) (*restclient.Config, error) {
 	config, err := loadConfig(path)
 	if err != nil {
 		return nil, err
 	}
-	if err = initializeWithDefaults(config); err != nil {
+	if err = initializeWithDefaults(config, clientConfig); err != nil {
 		return nil, fmt.Errorf("config initialization error: %v", err)
 	}
 	return config, nil
 }
 
+// PrepareStreamingConfig creates client config for streaming connections (e.g. exec).
+// Unlike PrepareConfig, it doesn't set the custom transport, as streaming round trippers
+// are built from the TLS fields of the config, which are cleared in that case.
+func PrepareStreamingConfig(path string, clientConfig *clconfig.ClientConfig) (*restclient.Config, error) {
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if clientConfig != nil && clientConfig.UserAgent != "" {
+		config.UserAgent = clientConfig.UserAgent
+	}
+	return config, nil
+}
+
 func restclientConfig(path string) (*clientcmdapi.Config, error) {
 	c, err := clientcmd.LoadFromFile(path)
 	if err != nil {
@@ -63,10 +89,39 @@ func loadConfig(path string) (*restclient.Config, error) {
 	return clientcmd.NewDefaultClientConfig(*c, &clientcmd.ConfigOverrides{}).ClientConfig()
 }
 
-func initializeWithDefaults(config *restclient.Config) error {
-	config.ContentType = contentType
-	config.QPS = qps
-	config.Burst = burst
+// ValidateClientConfig checks whether given client config is correct.
+// Negative QPS is allowed, it disables client-side throttling.
+func ValidateClientConfig(clientConfig *clconfig.ClientConfig) error {
+	if clientConfig.Burst < 0 || clientConfig.ClientsNumber < 0 {
+		return fmt.Errorf("client burst and number of clients cannot be negative")
+	}
+	if _, ok := contentTypes[clientConfig.ContentType]; clientConfig.ContentType != "" && !ok {
+		return fmt.Errorf("unknown content type %q, supported ones are %q and %q", clientConfig.ContentType, ContentTypeProtobuf, ContentTypeJSON)
+	}
+	return nil
+}
+
+func initializeWithDefaults(config *restclient.Config, clientConfig *clconfig.ClientConfig) error {
+	config.ContentType = contentTypes[ContentTypeProtobuf]
+	config.QPS = DefaultQPS
+	config.Burst = DefaultBurst
+	if clientConfig != nil {
+		if err := ValidateClientConfig(clientConfig); err != nil {
+			return err
+		}
+		if clientConfig.ContentType != "" {
+			config.ContentType = contentTypes[clientConfig.ContentType]
+		}
+		if clientConfig.QPS != 0 {
+			config.QPS = float32(clientConfig.QPS)
+		}
+		if clientConfig.Burst > 0 {
+			config.Burst = clientConfig.Burst
+		}
+		if clientConfig.UserAgent != "" {
+			config.UserAgent = clientConfig.UserAgent
+		}
+	}
 
 	// For the purpose of this test, we want to force that clients
 	// do not share underlying transport (which is a default behavior
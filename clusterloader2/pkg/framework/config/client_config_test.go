@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	restclient "k8s.io/client-go/rest"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
+)
+
+const kubeConfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test-token
+`
+
+func TestPrepareStreamingConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	kubeConfig, err := ioutil.TempFile("", "kubeconfig")
+	if err != nil {
+		t.Fatalf("creating kubeconfig error: %v", err)
+	}
+	defer os.Remove(kubeConfig.Name())
+	if _, err := fmt.Fprintf(kubeConfig, kubeConfigTemplate, server.URL, base64.StdEncoding.EncodeToString(ca)); err != nil {
+		t.Fatalf("writing kubeconfig error: %v", err)
+	}
+	kubeConfig.Close()
+
+	cfg, err := PrepareStreamingConfig(kubeConfig.Name(), &clconfig.ClientConfig{UserAgent: "test-agent"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, cfg.Transport)
+	assert.Equal(t, "test-agent", cfg.UserAgent)
+	assert.Equal(t, "test-token", cfg.BearerToken)
+	tlsConfig, err := restclient.TLSConfigFor(cfg)
+	assert.NoError(t, err)
+	if assert.NotNil(t, tlsConfig) {
+		assert.NotNil(t, tlsConfig.RootCAs)
+	}
+
+	// Server certificate is verified with the CA from the kubeconfig.
+	rt, err := restclient.TransportFor(cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+}
+
+func TestValidateClientConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		clientConfig clconfig.ClientConfig
+		wantErr      bool
+	}{
+		{name: "defaults", clientConfig: clconfig.ClientConfig{}},
+		{name: "negative qps", clientConfig: clconfig.ClientConfig{QPS: -1}},
+		{name: "negative burst", clientConfig: clconfig.ClientConfig{Burst: -1}, wantErr: true},
+		{name: "negative clients number", clientConfig: clconfig.ClientConfig{ClientsNumber: -1}, wantErr: true},
+		{name: "json content type", clientConfig: clconfig.ClientConfig{ContentType: ContentTypeJSON}},
+		{name: "unknown content type", clientConfig: clconfig.ClientConfig{ContentType: "yaml"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateClientConfig(&tc.clientConfig)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInitializeWithDefaultsQPS(t *testing.T) {
+	cases := []struct {
+		name    string
+		qps     int
+		wantQPS float32
+	}{
+		{name: "default", qps: 0, wantQPS: DefaultQPS},
+		{name: "custom", qps: 20, wantQPS: 20},
+		{name: "throttling disabled", qps: -1, wantQPS: -1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &restclient.Config{}
+			assert.NoError(t, initializeWithDefaults(cfg, &clconfig.ClientConfig{QPS: tc.qps}))
+			assert.Equal(t, tc.wantQPS, cfg.QPS)
+		})
+	}
+}
@@ -21,14 +21,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/klog"
 	"k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+	frameworkconfig "k8s.io/perf-tests/clusterloader2/pkg/framework/config"
 
 	// ensure auth plugins are loaded
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -42,6 +45,21 @@ type Framework struct {
 	clientSets                 *MultiClientSet
 	dynamicClients             *MultiDynamicClient
 	clusterConfig              *config.ClusterConfig
+	kubeConfigPath             string
+
+	identitiesLock sync.Mutex
+	// identities maps identity name to the clients impersonating it.
+	identities map[string]*identityClients
+
+	// restConfig is created once, on the first use.
+	restConfigOnce sync.Once
+	restConfig     *restclient.Config
+	restConfigErr  error
+}
+
+type identityClients struct {
+	clientSets     *MultiClientSet
+	dynamicClients *MultiDynamicClient
 }
 
 // NewFramework creates new framework based on given clusterConfig.
@@ -64,11 +82,12 @@ func newFramework(clusterConfig *config.ClusterConfig, clientsNumber int, kubeCo
 	f := Framework{
 		automanagedNamespaceCount: 0,
 		clusterConfig:             clusterConfig,
+		kubeConfigPath:            kubeConfigPath,
 	}
-	if f.clientSets, err = NewMultiClientSet(kubeConfigPath, clientsNumber); err != nil {
+	if f.clientSets, err = NewMultiClientSet(kubeConfigPath, clientsNumber, &clusterConfig.ClientConfig); err != nil {
 		return nil, fmt.Errorf("multi client set creation error: %v", err)
 	}
-	if f.dynamicClients, err = NewMultiDynamicClient(kubeConfigPath, clientsNumber); err != nil {
+	if f.dynamicClients, err = NewMultiDynamicClient(kubeConfigPath, clientsNumber, &clusterConfig.ClientConfig); err != nil {
 		return nil, fmt.Errorf("multi dynamic client creation error: %v", err)
 	}
 	return &f, nil
@@ -99,6 +118,57 @@ func (f *Framework) GetClusterConfig() *config.ClusterConfig {
 	return f.clusterConfig
 }
 
+// GetRestClientConfig returns rest client config of the cluster for streaming connections.
+// It is needed by the operations which aren't supported by the clientset, e.g. streaming exec.
+// The config is created on the first call and shared by the callers, so it must not be modified.
+func (f *Framework) GetRestClientConfig() (*restclient.Config, error) {
+	f.restConfigOnce.Do(func() {
+		f.restConfig, f.restConfigErr = frameworkconfig.PrepareStreamingConfig(f.kubeConfigPath, &f.clusterConfig.ClientConfig)
+	})
+	return f.restConfig, f.restConfigErr
+}
+
+// SetIdentities creates clients impersonating given identities (mapped by their names).
+// Previously set identities are removed.
+func (f *Framework) SetIdentities(identities map[string]restclient.ImpersonationConfig) error {
+	clientsNumber := f.clientSets.GetClientsNumber()
+	result := make(map[string]*identityClients, len(identities))
+	for name, impersonate := range identities {
+		clientSets, err := NewImpersonatingMultiClientSet(f.kubeConfigPath, clientsNumber, &f.clusterConfig.ClientConfig, impersonate)
+		if err != nil {
+			return fmt.Errorf("multi client set creation for identity %s error: %v", name, err)
+		}
+		dynamicClients, err := NewImpersonatingMultiDynamicClient(f.kubeConfigPath, clientsNumber, &f.clusterConfig.ClientConfig, impersonate)
+		if err != nil {
+			return fmt.Errorf("multi dynamic client creation for identity %s error: %v", name, err)
+		}
+		result[name] = &identityClients{clientSets: clientSets, dynamicClients: dynamicClients}
+	}
+	f.identitiesLock.Lock()
+	defer f.identitiesLock.Unlock()
+	f.identities = result
+	return nil
+}
+
+// ForIdentity returns framework which clients impersonate given identity.
+// Identity has to be set earlier with SetIdentities.
+func (f *Framework) ForIdentity(name string) (*Framework, error) {
+	f.identitiesLock.Lock()
+	defer f.identitiesLock.Unlock()
+	clients, ok := f.identities[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown identity %q", name)
+	}
+	return &Framework{
+		automanagedNamespacePrefix: f.automanagedNamespacePrefix,
+		automanagedNamespaceCount:  f.automanagedNamespaceCount,
+		clientSets:                 clients.clientSets,
+		dynamicClients:             clients.dynamicClients,
+		clusterConfig:              f.clusterConfig,
+		kubeConfigPath:             f.kubeConfigPath,
+	}, nil
+}
+
 // CreateAutomanagedNamespaces creates automanged namespaces.
 func (f *Framework) CreateAutomanagedNamespaces(namespaceCount int) error {
 	if f.automanagedNamespaceCount != 0 {
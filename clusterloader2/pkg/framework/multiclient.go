@@ -22,6 +22,8 @@ import (
 
 	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	clconfig "k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework/config"
 )
 
@@ -33,18 +35,32 @@ type MultiClientSet struct {
 }
 
 // NewMultiClientSet creates new MultiClientSet for given kubeconfig and number.
-func NewMultiClientSet(kubeconfigPath string, number int) (*MultiClientSet, error) {
+// Clients are configured according to clientConfig, nil means default configuration.
+func NewMultiClientSet(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig) (*MultiClientSet, error) {
+	return newMultiClientSet(kubeconfigPath, number, clientConfig, nil)
+}
+
+// NewImpersonatingMultiClientSet creates new MultiClientSet for given kubeconfig and number,
+// which clients impersonate given user and groups.
+func NewImpersonatingMultiClientSet(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig, impersonate restclient.ImpersonationConfig) (*MultiClientSet, error) {
+	return newMultiClientSet(kubeconfigPath, number, clientConfig, &impersonate)
+}
+
+func newMultiClientSet(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig, impersonate *restclient.ImpersonationConfig) (*MultiClientSet, error) {
 	m := MultiClientSet{
 		clients: make([]clientset.Interface, number),
 	}
 	for i := 0; i < number; i++ {
-		conf, err := config.PrepareConfig(kubeconfigPath)
+		conf, err := config.PrepareConfig(kubeconfigPath, clientConfig)
 		if err != nil {
 			return nil, fmt.Errorf("config prepare failed: %v", err)
 		}
 		if number < 1 {
 			return nil, fmt.Errorf("incorrect clients number")
 		}
+		if impersonate != nil {
+			conf.Impersonate = *impersonate
+		}
 		m.clients[i], err = clientset.NewForConfig(conf)
 		if err != nil {
 			return nil, fmt.Errorf("creating clientset failed: %v", err)
@@ -53,6 +69,11 @@ func NewMultiClientSet(kubeconfigPath string, number int) (*MultiClientSet, erro
 	return &m, nil
 }
 
+// GetClientsNumber returns the number of clients in the set.
+func (m *MultiClientSet) GetClientsNumber() int {
+	return len(m.clients)
+}
+
 // GetClient return one client instance from the set using round robin.
 func (m *MultiClientSet) GetClient() clientset.Interface {
 	m.lock.Lock()
@@ -69,18 +90,32 @@ type MultiDynamicClient struct {
 }
 
 // NewMultiDynamicClient creates new MultiDynamicClient for given kubeconfig and number.
-func NewMultiDynamicClient(kubeconfigPath string, number int) (*MultiDynamicClient, error) {
+// Clients are configured according to clientConfig, nil means default configuration.
+func NewMultiDynamicClient(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig) (*MultiDynamicClient, error) {
+	return newMultiDynamicClient(kubeconfigPath, number, clientConfig, nil)
+}
+
+// NewImpersonatingMultiDynamicClient creates new MultiDynamicClient for given kubeconfig and number,
+// which clients impersonate given user and groups.
+func NewImpersonatingMultiDynamicClient(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig, impersonate restclient.ImpersonationConfig) (*MultiDynamicClient, error) {
+	return newMultiDynamicClient(kubeconfigPath, number, clientConfig, &impersonate)
+}
+
+func newMultiDynamicClient(kubeconfigPath string, number int, clientConfig *clconfig.ClientConfig, impersonate *restclient.ImpersonationConfig) (*MultiDynamicClient, error) {
 	m := MultiDynamicClient{
 		clients: make([]dynamic.Interface, number),
 	}
 	for i := 0; i < number; i++ {
-		conf, err := config.PrepareConfig(kubeconfigPath)
+		conf, err := config.PrepareConfig(kubeconfigPath, clientConfig)
 		if err != nil {
 			return nil, fmt.Errorf("config prepare failed: %v", err)
 		}
 		if number < 1 {
 			return nil, fmt.Errorf("incorrect clients number")
 		}
+		if impersonate != nil {
+			conf.Impersonate = *impersonate
+		}
 		m.clients[i], err = dynamic.NewForConfig(conf)
 		if err != nil {
 			return nil, fmt.Errorf("creating dynamic config failed: %v", err)
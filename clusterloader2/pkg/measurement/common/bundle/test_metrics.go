@@ -207,9 +207,11 @@ func createConfig(config *measurement.MeasurementConfig, overrides map[string]in
 	return &measurement.MeasurementConfig{
 		ClusterFramework:    config.ClusterFramework,
 		PrometheusFramework: config.PrometheusFramework,
+		PrometheusClient:    config.PrometheusClient,
 		Params:              params,
 		TemplateProvider:    config.TemplateProvider,
 		CloudProvider:       config.CloudProvider,
+		ChaosEvents:         config.ChaosEvents,
 	}
 }
 
@@ -0,0 +1,291 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	componentRestartsMeasurementName = "ComponentRestarts"
+	componentLabel                   = "component"
+	// leaderAnnotation is the annotation used by the endpoints and configmaps based leader election locks.
+	leaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+)
+
+var (
+	defaultRestartComponents        = []string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+	defaultLeaderElectionComponents = []string{"kube-controller-manager", "kube-scheduler"}
+)
+
+func init() {
+	if err := measurement.Register(componentRestartsMeasurementName, createComponentRestartsMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", componentRestartsMeasurementName, err)
+	}
+}
+
+func createComponentRestartsMeasurement() measurement.Measurement {
+	return &componentRestartsMeasurement{}
+}
+
+type componentRestartsMeasurement struct {
+	isRunning bool
+	namespace string
+	// restarts maps pod name to its component and restart count.
+	restarts map[string]podRestarts
+	// leaders maps component name to its leader election record.
+	leaders map[string]leaderRecord
+}
+
+type podRestarts struct {
+	Component string
+	// UID distinguishes pods recreated with the same name (e.g. mirror pods of the restarted static pods).
+	UID      types.UID
+	Restarts int32
+}
+
+// countRestarts returns the number of restarts of the pod between initial and final observations.
+// Recreation of the pod is counted as a restart, followed by the restarts of the new pod.
+func countRestarts(initial, final podRestarts) int32 {
+	if initial.UID != final.UID {
+		return 1 + final.Restarts
+	}
+	return final.Restarts - initial.Restarts
+}
+
+// leaderRecord contains the subset of leader election record fields used by the measurement.
+type leaderRecord struct {
+	HolderIdentity   string `json:"holderIdentity"`
+	LeaseTransitions int32  `json:"leaseTransitions"`
+}
+
+// Execute supports two actions:
+// - start - records restart counts of control plane pods (selected by the component label)
+//   and leader election records of the components using leader election.
+// - gather - compares restart counts and leader election records with the ones recorded at start.
+//   If any of the components restarted or lost its leadership, an error is returned.
+// Control plane pods are looked up in the namespace given by parameter (kube-system by default),
+// so components running outside of the cluster (e.g. on managed masters) are not tracked.
+func (c *componentRestartsMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+	client := config.ClusterFramework.GetClientSets().GetClient()
+	switch action {
+	case "start":
+		if c.isRunning {
+			klog.Infof("%s: measurement already running", c)
+			return nil, nil
+		}
+		namespace, err := util.GetStringOrDefault(config.Params, "namespace", metav1.NamespaceSystem)
+		if err != nil {
+			return nil, err
+		}
+		components, err := util.GetStringArrayOrDefault(config.Params, "components", defaultRestartComponents)
+		if err != nil {
+			return nil, err
+		}
+		leaderElectionComponents, err := util.GetStringArrayOrDefault(config.Params, "leaderElectionComponents", defaultLeaderElectionComponents)
+		if err != nil {
+			return nil, err
+		}
+		c.namespace = namespace
+		if c.restarts, err = c.getRestarts(client, components); err != nil {
+			return nil, err
+		}
+		if c.leaders, err = c.getLeaders(client, leaderElectionComponents); err != nil {
+			return nil, err
+		}
+		klog.Infof("%s: tracking %d control plane pods and %d leader election records", c, len(c.restarts), len(c.leaders))
+		c.isRunning = true
+		return nil, nil
+	case "gather":
+		if !c.isRunning {
+			return nil, fmt.Errorf("metric %s has not been started", c)
+		}
+		c.isRunning = false
+		return c.gather(client, config.Identifier)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (c *componentRestartsMeasurement) Dispose() {}
+
+// String returns a string representation of the measurement.
+func (*componentRestartsMeasurement) String() string {
+	return componentRestartsMeasurementName
+}
+
+func (c *componentRestartsMeasurement) gather(client clientset.Interface, identifier string) ([]measurement.Summary, error) {
+	components := make(map[string]bool)
+	for _, pod := range c.restarts {
+		components[pod.Component] = true
+	}
+	var componentNames []string
+	for component := range components {
+		componentNames = append(componentNames, component)
+	}
+	restarts, err := c.getRestarts(client, componentNames)
+	if err != nil {
+		return nil, err
+	}
+	var leaderElectionComponents []string
+	for component := range c.leaders {
+		leaderElectionComponents = append(leaderElectionComponents, component)
+	}
+	leaders, err := c.getLeaders(client, leaderElectionComponents)
+	if err != nil {
+		return nil, err
+	}
+
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	var violations []string
+	podNames := make([]string, 0, len(c.restarts))
+	for podName := range c.restarts {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+	for _, podName := range podNames {
+		initial := c.restarts[podName]
+		final, ok := restarts[podName]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("pod %s disappeared", podName))
+			continue
+		}
+		delta := countRestarts(initial, final)
+		if delta != 0 {
+			violations = append(violations, fmt.Sprintf("pod %s restarted %d times", podName, delta))
+		}
+		perfData.DataItems = append(perfData.DataItems, measurementutil.DataItem{
+			Data: map[string]float64{"Restarts": float64(delta)},
+			Unit: "count",
+			Labels: map[string]string{
+				"Component": initial.Component,
+				"Pod":       podName,
+			},
+		})
+	}
+
+	sort.Strings(leaderElectionComponents)
+	for _, component := range leaderElectionComponents {
+		initial, final := c.leaders[component], leaders[component]
+		transitions := final.LeaseTransitions - initial.LeaseTransitions
+		if initial.HolderIdentity != final.HolderIdentity || transitions != 0 {
+			violations = append(violations, fmt.Sprintf("%s leader changed from %q to %q (%d transitions)",
+				component, initial.HolderIdentity, final.HolderIdentity, transitions))
+		}
+		perfData.DataItems = append(perfData.DataItems, measurementutil.DataItem{
+			Data: map[string]float64{"LeaseTransitions": float64(transitions)},
+			Unit: "count",
+			Labels: map[string]string{
+				"Component": component,
+				"Leader":    final.HolderIdentity,
+			},
+		})
+	}
+
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", componentRestartsMeasurementName, identifier), "json", content)
+	if len(violations) > 0 {
+		err = errors.NewMetricViolationError("component restarts", strings.Join(violations, "; "))
+		klog.Errorf("%s: %v", c, err)
+		return []measurement.Summary{summary}, err
+	}
+	return []measurement.Summary{summary}, nil
+}
+
+// getRestarts returns restart counts of pods of given components.
+func (c *componentRestartsMeasurement) getRestarts(client clientset.Interface, components []string) (map[string]podRestarts, error) {
+	result := make(map[string]podRestarts)
+	if len(components) == 0 {
+		return result, nil
+	}
+	selector := fmt.Sprintf("%s in (%s)", componentLabel, strings.Join(components, ","))
+	pods, err := client.CoreV1().Pods(c.namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		restarts := podRestarts{Component: pod.Labels[componentLabel], UID: pod.UID}
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts.Restarts += status.RestartCount
+		}
+		result[pod.Name] = restarts
+	}
+	return result, nil
+}
+
+// getLeaders returns leader election records of given components.
+// Both lease and endpoints based locks are supported, components without any lock are skipped.
+func (c *componentRestartsMeasurement) getLeaders(client clientset.Interface, components []string) (map[string]leaderRecord, error) {
+	result := make(map[string]leaderRecord)
+	for _, component := range components {
+		lease, err := client.CoordinationV1().Leases(c.namespace).Get(component, metav1.GetOptions{})
+		if err == nil {
+			var record leaderRecord
+			if lease.Spec.HolderIdentity != nil {
+				record.HolderIdentity = *lease.Spec.HolderIdentity
+			}
+			if lease.Spec.LeaseTransitions != nil {
+				record.LeaseTransitions = *lease.Spec.LeaseTransitions
+			}
+			result[component] = record
+			continue
+		}
+		if !apierrs.IsNotFound(err) {
+			return nil, err
+		}
+		endpoints, err := client.CoreV1().Endpoints(c.namespace).Get(component, metav1.GetOptions{})
+		if apierrs.IsNotFound(err) {
+			klog.Warningf("%s: leader election record of %s not found", c, component)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		annotation, ok := endpoints.Annotations[leaderAnnotation]
+		if !ok {
+			klog.Warningf("%s: leader election record of %s not found", c, component)
+			continue
+		}
+		var record leaderRecord
+		if err := json.Unmarshal([]byte(annotation), &record); err != nil {
+			return nil, fmt.Errorf("parsing leader election record of %s error: %v", component, err)
+		}
+		result[component] = record
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+)
+
+// fakePodsClientset implements listing of pods only, calling any other method panics.
+// Pods are listed by namespace, selectors are ignored.
+type fakePodsClientset struct {
+	clientset.Interface
+	pods []v1.Pod
+}
+
+func (f *fakePodsClientset) CoreV1() corev1client.CoreV1Interface {
+	return &fakePodsCoreV1{pods: f.pods}
+}
+
+type fakePodsCoreV1 struct {
+	corev1client.CoreV1Interface
+	pods []v1.Pod
+}
+
+func (f *fakePodsCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return &fakePodList{namespace: namespace, pods: f.pods}
+}
+
+type fakePodList struct {
+	corev1client.PodInterface
+	namespace string
+	pods      []v1.Pod
+}
+
+func (f *fakePodList) List(_ metav1.ListOptions) (*v1.PodList, error) {
+	podList := &v1.PodList{}
+	for _, pod := range f.pods {
+		if pod.Namespace == f.namespace {
+			podList.Items = append(podList.Items, pod)
+		}
+	}
+	return podList, nil
+}
+
+func newControlPlanePod(name, uid string, restarts int32) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceSystem,
+			UID:       types.UID(uid),
+			Labels:    map[string]string{componentLabel: "kube-apiserver"},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{RestartCount: restarts}},
+		},
+	}
+}
+
+func TestCountRestarts(t *testing.T) {
+	cases := []struct {
+		name    string
+		initial podRestarts
+		final   podRestarts
+		want    int32
+	}{
+		{
+			name:    "no restarts",
+			initial: podRestarts{UID: "a", Restarts: 2},
+			final:   podRestarts{UID: "a", Restarts: 2},
+			want:    0,
+		},
+		{
+			name:    "container restarted",
+			initial: podRestarts{UID: "a", Restarts: 2},
+			final:   podRestarts{UID: "a", Restarts: 3},
+			want:    1,
+		},
+		{
+			name:    "pod recreated",
+			initial: podRestarts{UID: "a", Restarts: 5},
+			final:   podRestarts{UID: "b", Restarts: 0},
+			want:    1,
+		},
+		{
+			name:    "pod recreated and restarted",
+			initial: podRestarts{UID: "a", Restarts: 5},
+			final:   podRestarts{UID: "b", Restarts: 2},
+			want:    3,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, countRestarts(tc.initial, tc.final))
+		})
+	}
+}
+
+func TestComponentRestartsGather(t *testing.T) {
+	cases := []struct {
+		name          string
+		initial       []v1.Pod
+		final         []v1.Pod
+		wantViolation bool
+	}{
+		{
+			name:    "no restarts",
+			initial: []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 3)},
+			final:   []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 3)},
+		},
+		{
+			name:          "container restarted",
+			initial:       []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 3)},
+			final:         []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 4)},
+			wantViolation: true,
+		},
+		{
+			name:          "pod recreated with lower restart count",
+			initial:       []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 3)},
+			final:         []v1.Pod{newControlPlanePod("kube-apiserver-master", "b", 0)},
+			wantViolation: true,
+		},
+		{
+			name:          "pod disappeared",
+			initial:       []v1.Pod{newControlPlanePod("kube-apiserver-master", "a", 3)},
+			wantViolation: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &componentRestartsMeasurement{namespace: metav1.NamespaceSystem}
+			var err error
+			c.restarts, err = c.getRestarts(&fakePodsClientset{pods: tc.initial}, []string{"kube-apiserver"})
+			assert.NoError(t, err)
+
+			summaries, err := c.gather(&fakePodsClientset{pods: tc.final}, "test")
+			assert.Len(t, summaries, 1)
+			if tc.wantViolation {
+				assert.True(t, errors.IsMetricViolationError(err), "got error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
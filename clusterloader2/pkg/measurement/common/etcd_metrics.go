@@ -26,6 +26,7 @@ import (
 	"k8s.io/klog"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
 	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 )
 
@@ -66,7 +67,7 @@ func (e *etcdMetricsMeasurement) Execute(config *measurement.MeasurementConfig)
 	if err != nil {
 		return nil, err
 	}
-	host, err := util.GetStringOrDefault(config.Params, "host", config.ClusterFramework.GetClusterConfig().GetMasterIp())
+	access, err := createMasterAccess(config, "")
 	if err != nil {
 		return nil, err
 	}
@@ -78,10 +79,10 @@ func (e *etcdMetricsMeasurement) Execute(config *measurement.MeasurementConfig)
 		if err != nil {
 			return nil, err
 		}
-		e.startCollecting(host, provider, waitTime)
+		e.startCollecting(access, provider, waitTime)
 		return nil, nil
 	case "gather":
-		if err = e.stopAndSummarize(host, provider); err != nil {
+		if err = e.stopAndSummarize(access, provider); err != nil {
 			return nil, err
 		}
 		content, err := util.PrettyPrintJSON(e.metrics)
@@ -108,7 +109,7 @@ func (e *etcdMetricsMeasurement) String() string {
 	return etcdMetricsMetricName
 }
 
-func (e *etcdMetricsMeasurement) startCollecting(host, provider string, interval time.Duration) {
+func (e *etcdMetricsMeasurement) startCollecting(access masteraccess.MasterAccess, provider string, interval time.Duration) {
 	e.isRunning = true
 	e.wg.Add(1)
 	go func() {
@@ -116,7 +117,7 @@ func (e *etcdMetricsMeasurement) startCollecting(host, provider string, interval
 		for {
 			select {
 			case <-time.After(interval):
-				dbSize, err := e.getEtcdDatabaseSize(host, provider)
+				dbSize, err := e.getEtcdDatabaseSize(access, provider)
 				if err != nil {
 					klog.Errorf("%s: failed to collect etcd database size", e)
 					continue
@@ -129,10 +130,10 @@ func (e *etcdMetricsMeasurement) startCollecting(host, provider string, interval
 	}()
 }
 
-func (e *etcdMetricsMeasurement) stopAndSummarize(host, provider string) error {
+func (e *etcdMetricsMeasurement) stopAndSummarize(access masteraccess.MasterAccess, provider string) error {
 	defer e.Dispose()
 	// Do some one-off collection of metrics.
-	samples, err := e.getEtcdMetrics(host, provider)
+	samples, err := e.getEtcdMetrics(access, provider)
 	if err != nil {
 		return err
 	}
@@ -151,9 +152,9 @@ func (e *etcdMetricsMeasurement) stopAndSummarize(host, provider string) error {
 	return nil
 }
 
-func (e *etcdMetricsMeasurement) getEtcdMetrics(host, provider string) ([]*model.Sample, error) {
-	// Etcd is only exposed on localhost level. We are using ssh method
-	if provider == "gke" {
+func (e *etcdMetricsMeasurement) getEtcdMetrics(access masteraccess.MasterAccess, provider string) ([]*model.Sample, error) {
+	// Etcd is only exposed on localhost level. SSHing to gke master is not supported.
+	if provider == "gke" && access.String() == masteraccess.SSH {
 		klog.Infof("%s: not grabbing etcd metrics through master SSH: unsupported for gke", e)
 		return nil, nil
 	}
@@ -161,26 +162,33 @@ func (e *etcdMetricsMeasurement) getEtcdMetrics(host, provider string) ([]*model
 	// In https://github.com/kubernetes/kubernetes/pull/74690, mTLS is enabled for etcd server
 	// http://localhost:2382 is specified to bypass TLS credential requirement when checking
 	// etcd /metrics and /health.
-	if samples, err := e.sshEtcdMetrics("curl http://localhost:2382/metrics", host, provider); err == nil {
+	if samples, err := e.requestEtcdMetrics(access, "etcd-server", 2382); err == nil {
 		return samples, nil
 	}
 
 	// Use old endpoint if new one fails.
-	return e.sshEtcdMetrics("curl http://localhost:2379/metrics", host, provider)
-}
+	samples, err := e.requestEtcdMetrics(access, "etcd-server", 2379)
+	if err == nil {
+		return samples, nil
+	}
 
-func (e *etcdMetricsMeasurement) sshEtcdMetrics(cmd, host, provider string) ([]*model.Sample, error) {
-	sshResult, err := measurementutil.SSH(cmd, host+":22", provider)
-	if err != nil || sshResult.Code != 0 {
-		return nil, fmt.Errorf("unexpected error (code: %d) in ssh connection to master: %#v", sshResult.Code, err)
+	// Etcd set up by kubeadm exposes metrics on a dedicated port.
+	if samples, kubeadmErr := e.requestEtcdMetrics(access, "etcd", 2381); kubeadmErr == nil {
+		return samples, nil
 	}
-	data := sshResult.Stdout
+	return nil, err
+}
 
+func (e *etcdMetricsMeasurement) requestEtcdMetrics(access masteraccess.MasterAccess, component string, port int) ([]*model.Sample, error) {
+	data, err := access.Request(component, "GET", port, "/metrics")
+	if err != nil {
+		return nil, err
+	}
 	return measurementutil.ExtractMetricSamples(data)
 }
 
-func (e *etcdMetricsMeasurement) getEtcdDatabaseSize(host, provider string) (float64, error) {
-	samples, err := e.getEtcdMetrics(host, provider)
+func (e *etcdMetricsMeasurement) getEtcdDatabaseSize(access masteraccess.MasterAccess, provider string) (float64, error) {
+	samples, err := e.getEtcdMetrics(access, provider)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,239 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/execservice"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	execCommandMeasurementName = "ExecCommand"
+	defaultExecCommandTimeout  = 5 * time.Minute
+)
+
+func init() {
+	if err := measurement.Register(execCommandMeasurementName, createExecCommandMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", execCommandMeasurementName, err)
+	}
+}
+
+func createExecCommandMeasurement() measurement.Measurement {
+	return &execCommandMeasurement{}
+}
+
+type execCommandMeasurement struct{}
+
+// Execute runs the command in exec pods (exec service has to be enabled)
+// and parses its output into PerfData. Params:
+// - command - shell command to run.
+// - allPods - whether to run the command in every exec pod (random one by default).
+// - timeout - timeout of the command.
+// - regexp - regular expression with named groups, every match is converted into data item
+//   with values of the named groups.
+// - jsonPaths - list of name and path (JSONPath) pairs, output is parsed as JSON
+//   and converted into a single data item with values at given paths.
+// - unit - unit of the data items.
+func (e *execCommandMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	command, err := util.GetString(config.Params, "command")
+	if err != nil {
+		return nil, err
+	}
+	allPods, err := util.GetBoolOrDefault(config.Params, "allPods", false)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := util.GetDurationOrDefault(config.Params, "timeout", defaultExecCommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := util.GetStringOrDefault(config.Params, "unit", "")
+	if err != nil {
+		return nil, err
+	}
+	parser, err := newOutputParser(config.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	podNames := []string{""}
+	if allPods {
+		if podNames, err = execservice.ListPodNames(); err != nil {
+			return nil, err
+		}
+	}
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	for _, podName := range podNames {
+		klog.Infof("%s: running %q", e, command)
+		output, err := execservice.RunCommandWithOptions(command, &execservice.CommandOptions{PodName: podName, Timeout: timeout})
+		if err != nil {
+			return nil, fmt.Errorf("command %q failed: %v, output: %s", command, err, output)
+		}
+		items, err := parser.parse(output)
+		if err != nil {
+			return nil, fmt.Errorf("parsing output of %q error: %v, output: %s", command, err, output)
+		}
+		for i := range items {
+			items[i].Unit = unit
+			if podName != "" {
+				items[i].Labels["Pod"] = podName
+			}
+		}
+		perfData.DataItems = append(perfData.DataItems, items...)
+	}
+
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", execCommandMeasurementName, config.Identifier), "json", content)
+	return []measurement.Summary{summary}, nil
+}
+
+// Dispose cleans up after the measurement.
+func (*execCommandMeasurement) Dispose() {}
+
+// String returns string representation of this measurement.
+func (*execCommandMeasurement) String() string {
+	return execCommandMeasurementName
+}
+
+// outputParser converts command output into data items.
+// Exactly one of re and jsonPaths is set.
+type outputParser struct {
+	re        *regexp.Regexp
+	jsonPaths map[string]*jsonpath.JSONPath
+}
+
+func newOutputParser(params map[string]interface{}) (*outputParser, error) {
+	pattern, err := util.GetStringOrDefault(params, "regexp", "")
+	if err != nil {
+		return nil, err
+	}
+	paths, err := util.GetMapArrayOrDefault(params, "jsonPaths", nil)
+	if err != nil {
+		return nil, err
+	}
+	if (pattern == "") == (len(paths) == 0) {
+		return nil, fmt.Errorf("exactly one of regexp and jsonPaths has to be specified")
+	}
+
+	parser := &outputParser{}
+	if pattern != "" {
+		if parser.re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regexp %q: %v", pattern, err)
+		}
+		return parser, nil
+	}
+	parser.jsonPaths = make(map[string]*jsonpath.JSONPath)
+	for _, p := range paths {
+		name, err := util.GetString(p, "name")
+		if err != nil {
+			return nil, err
+		}
+		path, err := util.GetString(p, "path")
+		if err != nil {
+			return nil, err
+		}
+		j := jsonpath.New(name)
+		if err := j.Parse(path); err != nil {
+			return nil, fmt.Errorf("parsing JSONPath %s error: %v", path, err)
+		}
+		parser.jsonPaths[name] = j
+	}
+	return parser, nil
+}
+
+func (p *outputParser) parse(output string) ([]measurementutil.DataItem, error) {
+	if p.re != nil {
+		return p.parseRegexp(output)
+	}
+	return p.parseJSON(output)
+}
+
+// parseRegexp creates data item from every match. If there are many matches,
+// items are labeled with the match index.
+func (p *outputParser) parseRegexp(output string) ([]measurementutil.DataItem, error) {
+	matches := p.re.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no matches of %q", p.re)
+	}
+	var items []measurementutil.DataItem
+	for i, match := range matches {
+		item := measurementutil.DataItem{Data: make(map[string]float64), Labels: make(map[string]string)}
+		for j, name := range p.re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(match[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("value of %s is not a number: %v", name, err)
+			}
+			item.Data[name] = value
+		}
+		if len(matches) > 1 {
+			item.Labels["Match"] = strconv.Itoa(i)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (p *outputParser) parseJSON(output string) ([]measurementutil.DataItem, error) {
+	var content interface{}
+	if err := json.Unmarshal([]byte(output), &content); err != nil {
+		return nil, fmt.Errorf("output is not a valid JSON: %v", err)
+	}
+	item := measurementutil.DataItem{Data: make(map[string]float64), Labels: make(map[string]string)}
+	for name, j := range p.jsonPaths {
+		results, err := j.FindResults(content)
+		if err != nil {
+			return nil, fmt.Errorf("finding %s error: %v", name, err)
+		}
+		if len(results) != 1 || len(results[0]) != 1 {
+			return nil, fmt.Errorf("%s has to match exactly one value", name)
+		}
+		value := results[0][0]
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		switch value.Kind() {
+		case reflect.Float64:
+			item.Data[name] = value.Float()
+		case reflect.String:
+			f, err := strconv.ParseFloat(value.String(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("value of %s is not a number: %v", name, err)
+			}
+			item.Data[name] = f
+		default:
+			return nil, fmt.Errorf("value of %s is not a number: %v", name, value)
+		}
+	}
+	return []measurementutil.DataItem{item}, nil
+}
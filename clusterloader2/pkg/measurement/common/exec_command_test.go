@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+func TestOutputParserRegexp(t *testing.T) {
+	parser, err := newOutputParser(map[string]interface{}{
+		"regexp": `(?P<Requests>\d+) requests in (?P<Seconds>[\d.]+)s`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items, err := parser.parse("100 requests in 1.5s\n200 requests in 2s\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []measurementutil.DataItem{
+		{Data: map[string]float64{"Requests": 100, "Seconds": 1.5}, Labels: map[string]string{"Match": "0"}},
+		{Data: map[string]float64{"Requests": 200, "Seconds": 2}, Labels: map[string]string{"Match": "1"}},
+	}, items)
+
+	_, err = parser.parse("no requests")
+	assert.Error(t, err)
+}
+
+func TestOutputParserJSON(t *testing.T) {
+	parser, err := newOutputParser(map[string]interface{}{
+		"jsonPaths": []interface{}{
+			map[string]interface{}{"name": "Perc99", "path": "{.latency.p99}"},
+			map[string]interface{}{"name": "QPS", "path": "{.qps}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items, err := parser.parse(`{"latency": {"p50": 1, "p99": 12.5}, "qps": "300"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []measurementutil.DataItem{
+		{Data: map[string]float64{"Perc99": 12.5, "QPS": 300}, Labels: map[string]string{}},
+	}, items)
+
+	_, err = parser.parse(`{"latency": {"p99": "slow"}, "qps": 1}`)
+	assert.Error(t, err)
+	_, err = parser.parse(`not json`)
+	assert.Error(t, err)
+}
+
+func TestOutputParserInvalidParams(t *testing.T) {
+	for _, params := range []map[string]interface{}{
+		{},
+		{"regexp": "(?P<A>\\d+)", "jsonPaths": []interface{}{map[string]interface{}{"name": "A", "path": "{.a}"}}},
+		{"regexp": "("},
+		{"jsonPaths": []interface{}{map[string]interface{}{"name": "A"}}},
+	} {
+		_, err := newOutputParser(params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/kubelet"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	kubeletRuntimeMetricsName   = "KubeletRuntimeMetrics"
+	defaultWorstNodesCount      = 5
+	kubeletMetricsScrapeWorkers = 10
+	kubeletOperationLabel       = "operation_type"
+)
+
+// kubeletRuntimeHistograms lists kubelet histograms gathered by the measurement.
+var kubeletRuntimeHistograms = []string{
+	"kubelet_pleg_relist_duration_seconds",
+	"kubelet_runtime_operations_duration_seconds",
+	"kubelet_pod_worker_duration_seconds",
+}
+
+func init() {
+	if err := measurement.Register(kubeletRuntimeMetricsName, createKubeletRuntimeMetricsMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", kubeletRuntimeMetricsName, err)
+	}
+}
+
+func createKubeletRuntimeMetricsMeasurement() measurement.Measurement {
+	return &kubeletRuntimeMetricsMeasurement{}
+}
+
+type kubeletRuntimeMetricsMeasurement struct {
+	isRunning       bool
+	startHistograms map[string]kubeletHistograms
+}
+
+// kubeletMetricKey identifies a single kubelet histogram.
+type kubeletMetricKey struct {
+	Metric    string
+	Operation string
+}
+
+// kubeletHistograms maps histogram identifier to its buckets.
+type kubeletHistograms map[kubeletMetricKey]measurementutil.BucketCounts
+
+// Execute supports two actions:
+// - start - scrapes kubelet metrics of every node.
+// - gather - scrapes kubelet metrics of every node again and creates summary
+//   of PLEG relist, runtime operations and pod worker latencies observed in between.
+//   Cluster-wide percentiles are reported together with worstNodesCount nodes
+//   with the highest 99th percentile.
+func (k *kubeletRuntimeMetricsMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+	c := config.ClusterFramework.GetClientSets().GetClient()
+	switch action {
+	case "start":
+		if k.isRunning {
+			klog.Infof("%s: measurement already running", k)
+			return nil, nil
+		}
+		klog.Infof("%s: scraping initial kubelet metrics...", k)
+		histograms, err := k.scrapeNodes(c)
+		if err != nil {
+			return nil, err
+		}
+		k.startHistograms = histograms
+		k.isRunning = true
+		return nil, nil
+	case "gather":
+		if !k.isRunning {
+			return nil, fmt.Errorf("metric %s has not been started", k)
+		}
+		worstNodesCount, err := util.GetIntOrDefault(config.Params, "worstNodesCount", defaultWorstNodesCount)
+		if err != nil {
+			return nil, err
+		}
+		k.isRunning = false
+		histograms, err := k.scrapeNodes(c)
+		if err != nil {
+			return nil, err
+		}
+		return k.createSummary(histograms, worstNodesCount, config.Identifier)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (k *kubeletRuntimeMetricsMeasurement) Dispose() {}
+
+// String returns a string representation of the measurement.
+func (*kubeletRuntimeMetricsMeasurement) String() string {
+	return kubeletRuntimeMetricsName
+}
+
+// scrapeNodes returns kubelet histograms of every node.
+// Nodes that cannot be scraped are skipped, but an error is returned if none of the nodes could be scraped.
+func (k *kubeletRuntimeMetricsMeasurement) scrapeNodes(c clientset.Interface) (map[string]kubeletHistograms, error) {
+	nodes, err := client.ListNodes(c)
+	if err != nil {
+		return nil, err
+	}
+	var lock sync.Mutex
+	result := make(map[string]kubeletHistograms, len(nodes))
+	workqueue.Parallelize(kubeletMetricsScrapeWorkers, len(nodes), func(i int) {
+		nodeName := nodes[i].Name
+		data, err := kubelet.GetMetrics(c, nodeName)
+		if err != nil {
+			klog.Errorf("%s: scraping kubelet metrics of node %s error: %v", k, nodeName, err)
+			return
+		}
+		samples, err := measurementutil.ExtractMetricSamples(data)
+		if err != nil {
+			klog.Errorf("%s: parsing kubelet metrics of node %s error: %v", k, nodeName, err)
+			return
+		}
+		histograms := parseKubeletHistograms(samples)
+		lock.Lock()
+		defer lock.Unlock()
+		result[nodeName] = histograms
+	})
+	if len(nodes) > 0 && len(result) == 0 {
+		return nil, fmt.Errorf("scraping kubelet metrics failed for all %d nodes", len(nodes))
+	}
+	return result, nil
+}
+
+func parseKubeletHistograms(samples []*model.Sample) kubeletHistograms {
+	histograms := make(kubeletHistograms)
+	for _, sample := range samples {
+		name := string(sample.Metric[model.MetricNameLabel])
+		for _, metric := range kubeletRuntimeHistograms {
+			if name != metric+"_bucket" {
+				continue
+			}
+			le, err := strconv.ParseFloat(string(sample.Metric[model.BucketLabel]), 64)
+			if err != nil {
+				continue
+			}
+			key := kubeletMetricKey{Metric: metric, Operation: string(sample.Metric[kubeletOperationLabel])}
+			if _, ok := histograms[key]; !ok {
+				histograms[key] = make(measurementutil.BucketCounts)
+			}
+			histograms[key][le] += float64(sample.Value)
+		}
+	}
+	return histograms
+}
+
+func (k *kubeletRuntimeMetricsMeasurement) createSummary(histograms map[string]kubeletHistograms, worstNodesCount int, identifier string) ([]measurement.Summary, error) {
+	clusterHistograms := make(kubeletHistograms)
+	nodeLatencies := make(map[kubeletMetricKey][]nodeLatency)
+	for nodeName, nodeHistograms := range histograms {
+		for key, buckets := range nodeHistograms {
+			delta := buckets.Subtract(k.startHistograms[nodeName][key])
+			if delta.Count() < 0 {
+				// Counters have been reset, i.e. kubelet has been restarted.
+				delta = buckets
+			}
+			if delta.Count() <= 0 {
+				continue
+			}
+			if _, ok := clusterHistograms[key]; !ok {
+				clusterHistograms[key] = make(measurementutil.BucketCounts)
+			}
+			clusterHistograms[key].Add(delta)
+			nodeLatencies[key] = append(nodeLatencies[key], nodeLatency{node: nodeName, buckets: delta})
+		}
+	}
+
+	keys := make([]kubeletMetricKey, 0, len(clusterHistograms))
+	for key := range clusterHistograms {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Metric != keys[j].Metric {
+			return keys[i].Metric < keys[j].Metric
+		}
+		return keys[i].Operation < keys[j].Operation
+	})
+
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	for _, key := range keys {
+		item := bucketsToPerfData(key, clusterHistograms[key])
+		perfData.DataItems = append(perfData.DataItems, item)
+
+		latencies := nodeLatencies[key]
+		sort.Slice(latencies, func(i, j int) bool {
+			return latencies[i].buckets.Quantile(0.99) > latencies[j].buckets.Quantile(0.99)
+		})
+		for i := 0; i < len(latencies) && i < worstNodesCount; i++ {
+			nodeItem := bucketsToPerfData(key, latencies[i].buckets)
+			nodeItem.Labels["Node"] = latencies[i].node
+			perfData.DataItems = append(perfData.DataItems, nodeItem)
+			klog.Infof("%s: %s %s: worst node %s: perc99 %vms", k, key.Metric, key.Operation, latencies[i].node, nodeItem.Data["Perc99"])
+		}
+	}
+
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", kubeletRuntimeMetricsName, identifier), "json", content)
+	return []measurement.Summary{summary}, nil
+}
+
+type nodeLatency struct {
+	node    string
+	buckets measurementutil.BucketCounts
+}
+
+func bucketsToPerfData(key kubeletMetricKey, buckets measurementutil.BucketCounts) measurementutil.DataItem {
+	toMs := func(seconds float64) float64 {
+		return math.Round(seconds*1000*1000) / 1000
+	}
+	return measurementutil.DataItem{
+		Data: map[string]float64{
+			"Perc50": toMs(buckets.Quantile(0.5)),
+			"Perc90": toMs(buckets.Quantile(0.9)),
+			"Perc99": toMs(buckets.Quantile(0.99)),
+			"Count":  buckets.Count(),
+		},
+		Unit: "ms",
+		Labels: map[string]string{
+			"Metric":    key.Metric,
+			"Operation": key.Operation,
+		},
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+func TestKubeletRuntimeMetricsSummary(t *testing.T) {
+	inf := math.Inf(1)
+	key := kubeletMetricKey{Metric: "kubelet_pleg_relist_duration_seconds"}
+	k := &kubeletRuntimeMetricsMeasurement{
+		startHistograms: map[string]kubeletHistograms{
+			"node-a": {key: {0.1: 10, 1: 10, inf: 10}},
+			"node-b": {key: {0.1: 10, 1: 20, inf: 20}},
+		},
+	}
+	histograms := map[string]kubeletHistograms{
+		// 10 new observations below 100ms.
+		"node-a": {key: {0.1: 20, 1: 20, inf: 20}},
+		// 10 new observations between 100ms and 1s.
+		"node-b": {key: {0.1: 10, 1: 30, inf: 30}},
+		// Restarted kubelet, all observations are counted.
+		"node-c": {key: {0.1: 5, 1: 5, inf: 5}},
+	}
+
+	summaries, err := k.createSummary(histograms, 1, "test")
+	assert.NoError(t, err)
+	if !assert.Len(t, summaries, 1) {
+		return
+	}
+	var perfData measurementutil.PerfData
+	assert.NoError(t, json.Unmarshal([]byte(summaries[0].SummaryContent()), &perfData))
+	if !assert.Len(t, perfData.DataItems, 2) {
+		return
+	}
+
+	cluster, worstNode := perfData.DataItems[0], perfData.DataItems[1]
+	assert.Equal(t, map[string]string{"Metric": key.Metric, "Operation": ""}, cluster.Labels)
+	assert.Equal(t, 25.0, cluster.Data["Count"])
+	assert.Equal(t, map[string]string{"Metric": key.Metric, "Operation": "", "Node": "node-b"}, worstNode.Labels)
+	assert.Equal(t, 10.0, worstNode.Data["Count"])
+}
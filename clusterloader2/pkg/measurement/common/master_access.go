@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+// createMasterAccess creates master access for the measurement.
+// Access method, provider and host can be overridden with masterAccess, provider and host params.
+// If access method is not specified neither by param nor by flag, defaultMethod is used
+// (empty defaultMethod means that method is chosen based on the provider).
+func createMasterAccess(config *measurement.MeasurementConfig, defaultMethod string) (masteraccess.MasterAccess, error) {
+	clusterConfig := config.ClusterFramework.GetClusterConfig()
+	method, err := util.GetStringOrDefault(config.Params, "masterAccess", clusterConfig.MasterAccess)
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		method = defaultMethod
+	}
+	provider, err := util.GetStringOrDefault(config.Params, "provider", clusterConfig.Provider)
+	if err != nil {
+		return nil, err
+	}
+	// masterIP param is supported for backward compatibility.
+	masterIP, err := util.GetStringOrDefault(config.Params, "masterIP", clusterConfig.GetMasterIp())
+	if err != nil {
+		return nil, err
+	}
+	host, err := util.GetStringOrDefault(config.Params, "host", masterIP)
+	if err != nil {
+		return nil, err
+	}
+	masterName, err := util.GetStringOrDefault(config.Params, "masterName", clusterConfig.MasterName)
+	if err != nil {
+		return nil, err
+	}
+	accessConfig := &masteraccess.Config{
+		Method:     method,
+		Provider:   provider,
+		Host:       host,
+		MasterName: masterName,
+		Client:     config.ClusterFramework.GetClientSets().GetClient(),
+	}
+	if accessConfig.GetMethod() == masteraccess.Exec {
+		if accessConfig.RestConfig, err = config.ClusterFramework.GetRestClientConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return masteraccess.New(accessConfig)
+}
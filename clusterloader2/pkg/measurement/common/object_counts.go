@@ -0,0 +1,348 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	objectCountsMeasurementName = "ObjectCounts"
+	defaultObjectCountsInterval = time.Minute
+	defaultObjectCountsPageSize = 500
+
+	// objectCountQuery returns number of stored objects per resource.
+	// etcd_object_counts is the name of the metric before it was renamed to apiserver_storage_objects.
+	objectCountQuery = "max(apiserver_storage_objects) by (resource) or max(etcd_object_counts) by (resource)"
+	// peakObjectCountQuery returns maximal number of stored objects per resource.
+	// %v should be replaced with query window size.
+	peakObjectCountQuery = "max(max_over_time(apiserver_storage_objects[%v])) by (resource) or max(max_over_time(etcd_object_counts[%v])) by (resource)"
+)
+
+// defaultObjectCountsResources are resources counted if none are specified.
+var defaultObjectCountsResources = []map[string]interface{}{
+	{"apiVersion": "v1", "kind": "Pod"},
+	{"apiVersion": "v1", "kind": "Service"},
+	{"apiVersion": "v1", "kind": "Endpoints"},
+	{"apiVersion": "v1", "kind": "ConfigMap"},
+	{"apiVersion": "v1", "kind": "Secret"},
+	{"apiVersion": "v1", "kind": "Event"},
+	{"apiVersion": "v1", "kind": "ReplicationController"},
+	{"apiVersion": "apps/v1", "kind": "ReplicaSet"},
+	{"apiVersion": "apps/v1", "kind": "Deployment"},
+	{"apiVersion": "apps/v1", "kind": "DaemonSet"},
+	{"apiVersion": "batch/v1", "kind": "Job"},
+}
+
+func init() {
+	if err := measurement.Register(objectCountsMeasurementName, createObjectCountsMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", objectCountsMeasurementName, err)
+	}
+}
+
+func createObjectCountsMeasurement() measurement.Measurement {
+	return &objectCountsMeasurement{}
+}
+
+type objectCountsMeasurement struct {
+	isRunning bool
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	startTime time.Time
+	// executor is set if object counts are taken from Prometheus.
+	executor *measurementutil.PrometheusQueryExecutor
+	client   dynamic.Interface
+	pageSize int64
+
+	lock   sync.Mutex
+	counts map[schema.GroupVersionResource]*objectCount
+}
+
+type objectCount struct {
+	Initial int
+	Peak    int
+	Final   int
+}
+
+// Execute supports two actions:
+// - start - records initial number of objects of given resources
+//   and starts counting them periodically.
+//   Resources are specified as a list of apiVersion and kind pairs.
+//   If Prometheus server is enabled, counts are based on apiserver_storage_objects metric,
+//   otherwise paginated LIST calls are used.
+// - gather - records final number of objects and creates summary with initial, peak
+//   and final counts together with the growth rate for every resource.
+//   If enableViolations is set, the measurement fails when there are more objects
+//   than at the start, e.g. because they were leaked during namespace deletion.
+func (o *objectCountsMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+	switch action {
+	case "start":
+		if o.isRunning {
+			klog.Infof("%s: measurement already running", o)
+			return nil, nil
+		}
+		resources, err := util.GetMapArrayOrDefault(config.Params, "resources", defaultObjectCountsResources)
+		if err != nil {
+			return nil, err
+		}
+		interval, err := util.GetDurationOrDefault(config.Params, "interval", defaultObjectCountsInterval)
+		if err != nil {
+			return nil, err
+		}
+		pageSize, err := util.GetIntOrDefault(config.Params, "pageSize", defaultObjectCountsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		usePrometheus, err := util.GetBoolOrDefault(config.Params, "usePrometheus", true)
+		if err != nil {
+			return nil, err
+		}
+		o.executor = nil
+		if usePrometheus && config.PrometheusClient != nil {
+			o.executor = measurementutil.NewQueryExecutor(config.PrometheusClient)
+		}
+		o.client = config.ClusterFramework.GetDynamicClients().GetClient()
+		o.pageSize = int64(pageSize)
+		return nil, o.start(resources, interval)
+	case "gather":
+		enableViolations, err := util.GetBoolOrDefault(config.Params, "enableViolations", false)
+		if err != nil {
+			return nil, err
+		}
+		return o.gather(enableViolations, config.Identifier)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (o *objectCountsMeasurement) Dispose() {
+	o.stop()
+}
+
+// String returns a string representation of the measurement.
+func (*objectCountsMeasurement) String() string {
+	return objectCountsMeasurementName
+}
+
+func (o *objectCountsMeasurement) start(resources []map[string]interface{}, interval time.Duration) error {
+	o.counts = make(map[schema.GroupVersionResource]*objectCount)
+	for _, resource := range resources {
+		apiVersion, err := util.GetString(resource, "apiVersion")
+		if err != nil {
+			return err
+		}
+		kind, err := util.GetString(resource, "kind")
+		if err != nil {
+			return err
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return err
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(kind))
+		o.counts[gvr] = &objectCount{}
+	}
+
+	o.startTime = time.Now()
+	counts, err := o.countObjects()
+	if err != nil {
+		return err
+	}
+	for gvr, count := range counts {
+		o.counts[gvr].Initial = count
+		o.counts[gvr].Peak = count
+	}
+
+	klog.Infof("%s: starting counting objects", o)
+	o.isRunning = true
+	o.stopCh = make(chan struct{})
+	if o.executor != nil {
+		// Peak values are computed by Prometheus.
+		return nil
+	}
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		for {
+			select {
+			case <-o.stopCh:
+				return
+			case <-time.After(interval):
+				counts, err := o.countObjects()
+				if err != nil {
+					klog.Errorf("%s: counting objects error: %v", o, err)
+					continue
+				}
+				o.lock.Lock()
+				for gvr, count := range counts {
+					if count > o.counts[gvr].Peak {
+						o.counts[gvr].Peak = count
+					}
+				}
+				o.lock.Unlock()
+			}
+		}
+	}()
+	return nil
+}
+
+func (o *objectCountsMeasurement) stop() {
+	if o.isRunning {
+		o.isRunning = false
+		close(o.stopCh)
+		o.wg.Wait()
+	}
+}
+
+func (o *objectCountsMeasurement) gather(enableViolations bool, identifier string) ([]measurement.Summary, error) {
+	if !o.isRunning {
+		return nil, fmt.Errorf("metric %s has not been started", o)
+	}
+	o.stop()
+	klog.Infof("%s: gathering object counts", o)
+
+	duration := time.Since(o.startTime)
+	counts, err := o.countObjects()
+	if err != nil {
+		return nil, err
+	}
+	if o.executor != nil {
+		peaks, err := o.queryPrometheus(fmt.Sprintf(peakObjectCountQuery, measurementutil.ToPrometheusTime(duration), measurementutil.ToPrometheusTime(duration)))
+		if err != nil {
+			return nil, err
+		}
+		for gvr, peak := range peaks {
+			o.counts[gvr].Peak = peak
+		}
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	gvrs := make([]schema.GroupVersionResource, 0, len(o.counts))
+	for gvr, count := range o.counts {
+		count.Final = counts[gvr]
+		if count.Final > count.Peak {
+			count.Peak = count.Final
+		}
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	var leaked []string
+	for _, gvr := range gvrs {
+		count := o.counts[gvr]
+		growth := float64(count.Final-count.Initial) / duration.Minutes()
+		klog.Infof("%s: %v: initial: %d, peak: %d, final: %d, growth: %.2f/min", o, gvr, count.Initial, count.Peak, count.Final, growth)
+		if count.Final > count.Initial {
+			leaked = append(leaked, fmt.Sprintf("%s: %d", gvr.GroupResource().String(), count.Final-count.Initial))
+		}
+		perfData.DataItems = append(perfData.DataItems, measurementutil.DataItem{
+			Data: map[string]float64{
+				"Initial":         float64(count.Initial),
+				"Peak":            float64(count.Peak),
+				"Final":           float64(count.Final),
+				"GrowthPerMinute": growth,
+			},
+			Unit: "count",
+			Labels: map[string]string{
+				"Resource": gvr.GroupResource().String(),
+			},
+		})
+	}
+
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", objectCountsMeasurementName, identifier), "json", content)
+	if enableViolations && len(leaked) > 0 {
+		err = errors.NewMetricViolationError("object counts", fmt.Sprintf("there should be no objects left over, but: %v", leaked))
+		klog.Errorf("%s: %v", o, err)
+		return []measurement.Summary{summary}, err
+	}
+	return []measurement.Summary{summary}, nil
+}
+
+// countObjects returns current number of objects of every measured resource.
+func (o *objectCountsMeasurement) countObjects() (map[schema.GroupVersionResource]int, error) {
+	if o.executor != nil {
+		return o.queryPrometheus(objectCountQuery)
+	}
+	counts := make(map[schema.GroupVersionResource]int)
+	for gvr := range o.counts {
+		count, err := o.listObjects(gvr)
+		if err != nil {
+			return nil, err
+		}
+		counts[gvr] = count
+	}
+	return counts, nil
+}
+
+// listObjects counts objects of given resource using paginated list calls.
+func (o *objectCountsMeasurement) listObjects(gvr schema.GroupVersionResource) (int, error) {
+	count := 0
+	options := metav1.ListOptions{Limit: o.pageSize}
+	for {
+		list, err := o.client.Resource(gvr).List(options)
+		if err != nil {
+			return 0, fmt.Errorf("listing %v error: %v", gvr, err)
+		}
+		count += len(list.Items)
+		options.Continue = list.GetContinue()
+		if options.Continue == "" {
+			return count, nil
+		}
+	}
+}
+
+// queryPrometheus executes given query and returns values for the measured resources.
+func (o *objectCountsMeasurement) queryPrometheus(query string) (map[schema.GroupVersionResource]int, error) {
+	samples, err := o.executor.Query(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]int)
+	for _, sample := range samples {
+		values[string(sample.Metric[model.LabelName("resource")])] = int(sample.Value)
+	}
+	counts := make(map[schema.GroupVersionResource]int)
+	for gvr := range o.counts {
+		counts[gvr] = values[gvr.GroupResource().String()]
+	}
+	return counts, nil
+}
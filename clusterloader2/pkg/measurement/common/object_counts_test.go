@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+var (
+	podsResource    = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// fakeDynamicClient implements paginated listing only, calling any other method panics.
+// Continue token is the index of the first object of the next page.
+type fakeDynamicClient struct {
+	counts map[schema.GroupVersionResource]int
+	calls  int
+}
+
+func (f *fakeDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeResource{client: f, gvr: gvr}
+}
+
+type fakeResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *fakeDynamicClient
+	gvr    schema.GroupVersionResource
+}
+
+func (f *fakeResource) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	f.client.calls++
+	start := 0
+	if opts.Continue != "" {
+		var err error
+		if start, err = strconv.Atoi(opts.Continue); err != nil {
+			return nil, err
+		}
+	}
+	end := f.client.counts[f.gvr]
+	list := &unstructured.UnstructuredList{}
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+		list.SetContinue(strconv.Itoa(end))
+	}
+	for i := start; i < end; i++ {
+		list.Items = append(list.Items, unstructured.Unstructured{})
+	}
+	return list, nil
+}
+
+func TestListObjects(t *testing.T) {
+	cases := []struct {
+		name      string
+		count     int
+		pageSize  int64
+		wantCalls int
+	}{
+		{name: "empty", count: 0, pageSize: 3, wantCalls: 1},
+		{name: "single page", count: 3, pageSize: 3, wantCalls: 1},
+		{name: "multiple pages", count: 7, pageSize: 3, wantCalls: 3},
+		{name: "no limit", count: 7, pageSize: 0, wantCalls: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeDynamicClient{counts: map[schema.GroupVersionResource]int{podsResource: tc.count}}
+			o := &objectCountsMeasurement{client: client, pageSize: tc.pageSize}
+			count, err := o.listObjects(podsResource)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.count, count)
+			assert.Equal(t, tc.wantCalls, client.calls)
+		})
+	}
+}
+
+func TestObjectCountsGather(t *testing.T) {
+	resources := []map[string]interface{}{
+		{"apiVersion": "v1", "kind": "Pod"},
+		{"apiVersion": "v1", "kind": "Secret"},
+	}
+	cases := []struct {
+		name             string
+		initial          map[schema.GroupVersionResource]int
+		final            map[schema.GroupVersionResource]int
+		enableViolations bool
+		wantViolation    bool
+		wantData         map[string]map[string]float64
+	}{
+		{
+			name:             "no objects left over",
+			initial:          map[schema.GroupVersionResource]int{podsResource: 5, secretsResource: 2},
+			final:            map[schema.GroupVersionResource]int{podsResource: 4, secretsResource: 2},
+			enableViolations: true,
+			wantData: map[string]map[string]float64{
+				"pods":    {"Initial": 5, "Peak": 5, "Final": 4},
+				"secrets": {"Initial": 2, "Peak": 2, "Final": 2},
+			},
+		},
+		{
+			name:             "objects left over",
+			initial:          map[schema.GroupVersionResource]int{podsResource: 5, secretsResource: 2},
+			final:            map[schema.GroupVersionResource]int{podsResource: 7, secretsResource: 2},
+			enableViolations: true,
+			wantViolation:    true,
+			wantData: map[string]map[string]float64{
+				"pods":    {"Initial": 5, "Peak": 7, "Final": 7},
+				"secrets": {"Initial": 2, "Peak": 2, "Final": 2},
+			},
+		},
+		{
+			name:    "objects left over with violations disabled",
+			initial: map[schema.GroupVersionResource]int{podsResource: 5, secretsResource: 2},
+			final:   map[schema.GroupVersionResource]int{podsResource: 7, secretsResource: 3},
+			wantData: map[string]map[string]float64{
+				"pods":    {"Initial": 5, "Peak": 7, "Final": 7},
+				"secrets": {"Initial": 2, "Peak": 3, "Final": 3},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeDynamicClient{counts: tc.initial}
+			o := &objectCountsMeasurement{client: client, pageSize: 2}
+			assert.NoError(t, o.start(resources, time.Hour))
+
+			client.counts = tc.final
+			summaries, err := o.gather(tc.enableViolations, "test")
+			if tc.wantViolation {
+				assert.True(t, errors.IsMetricViolationError(err), "got error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !assert.Len(t, summaries, 1) {
+				return
+			}
+			var perfData measurementutil.PerfData
+			assert.NoError(t, json.Unmarshal([]byte(summaries[0].SummaryContent()), &perfData))
+			data := make(map[string]map[string]float64)
+			for _, item := range perfData.DataItems {
+				delete(item.Data, "GrowthPerMinute")
+				data[item.Labels["Resource"]] = item.Data
+			}
+			assert.Equal(t, tc.wantData, data)
+		})
+	}
+}
@@ -72,6 +72,7 @@ type probesMeasurement struct {
 	probeNameToPrometheusQueryTmpl map[string]string
 
 	framework        *framework.Framework
+	prometheusClient prometheus.Client
 	replicasPerProbe int
 	templateMapping  map[string]interface{}
 	startTime        time.Time
@@ -129,7 +130,11 @@ func (p *probesMeasurement) initialize(config *measurement.MeasurementConfig) er
 	if err != nil {
 		return err
 	}
+	if config.PrometheusClient == nil {
+		return fmt.Errorf("prometheus is required by %s", p)
+	}
 	p.framework = config.ClusterFramework
+	p.prometheusClient = config.PrometheusClient
 	p.replicasPerProbe = replicasPerProbe
 	p.templateMapping = map[string]interface{}{"Replicas": replicasPerProbe}
 	return nil
@@ -171,7 +176,7 @@ func (p *probesMeasurement) gather(params map[string]interface{}) ([]measurement
 	var violationErrors []error
 	for probeName, queryTmpl := range p.probeNameToPrometheusQueryTmpl {
 		query := prepareQuery(queryTmpl, p.startTime, measurementEnd)
-		executor := measurementutil.NewQueryExecutor(p.framework.GetClientSets().GetClient())
+		executor := measurementutil.NewQueryExecutor(p.prometheusClient)
 		samples, err := executor.Query(query, measurementEnd)
 		if err != nil {
 			return nil, err
@@ -224,8 +229,7 @@ func (p *probesMeasurement) checkProbesReady() (bool, error) {
 	// TODO(mm4tt): Using prometheus targets to check whether probes are up is a bit hacky.
 	//              Consider rewriting this to something more intuitive.
 	expectedTargets := p.replicasPerProbe * len(serviceMonitors.Items)
-	return prometheus.CheckTargetsReady(
-		p.framework.GetClientSets().GetClient(), isProbeTarget, expectedTargets)
+	return prometheus.CheckTargetsReady(p.prometheusClient, isProbeTarget, expectedTargets)
 }
 
 func isProbeTarget(t prometheus.Target) bool {
@@ -18,36 +18,76 @@ package common
 
 import (
 	"fmt"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
-	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 )
 
 const (
-	memoryProfileName = "MemoryProfile"
-	cpuProfileName    = "CPUProfile"
+	memoryProfileName    = "MemoryProfile"
+	cpuProfileName       = "CPUProfile"
+	goroutineProfileName = "GoroutineProfile"
+	blockProfileName     = "BlockProfile"
+	mutexProfileName     = "MutexProfile"
+
+	defaultProfilePath = "/debug/pprof/"
 )
 
+// profileKinds maps profile measurement names to the pprof profile kinds.
+var profileKinds = map[string]string{
+	memoryProfileName:    "heap",
+	cpuProfileName:       "profile",
+	goroutineProfileName: "goroutine",
+	blockProfileName:     "block",
+	mutexProfileName:     "mutex",
+}
+
+// defaultProfilePorts contains pprof ports of the components, for which port doesn't have to be specified.
+var defaultProfilePorts = map[string]int{
+	"kube-scheduler":          10251,
+	"kube-controller-manager": 10252,
+}
+
 func init() {
-	if err := measurement.Register(memoryProfileName, createMemoryProfileMeasurement); err != nil {
-		klog.Fatalf("Cannot register %s: %v", memoryProfileName, err)
+	for name, kind := range profileKinds {
+		if err := measurement.Register(name, createProfileMeasurementFunc(name, kind)); err != nil {
+			klog.Fatalf("Cannot register %s: %v", name, err)
+		}
 	}
-	if err := measurement.Register(cpuProfileName, createCPUProfileMeasurement); err != nil {
-		klog.Fatalf("Cannot register %s: %v", cpuProfileName, err)
+}
+
+func createProfileMeasurementFunc(name, kind string) func() measurement.Measurement {
+	return func() measurement.Measurement {
+		return &profileMeasurement{
+			name: name,
+			kind: kind,
+		}
 	}
 }
 
 type profileConfig struct {
 	componentName string
 	provider      string
-	host          string
-	kind          string
+	// masterAccess is used to fetch the profile from the master if the component can't be reached through its pods.
+	masterAccess masteraccess.MasterAccess
+	// namespace, labelSelector and serviceName describe how the component is accessed through the api server proxy.
+	namespace     string
+	labelSelector string
+	serviceName   string
+	scheme        string
+	port          int
+	path          string
+	// seconds is the duration of the cpu profile.
+	seconds  int
+	interval time.Duration
 }
 
 func createProfileConfig(config *measurement.MeasurementConfig) (*profileConfig, error) {
@@ -59,55 +99,121 @@ func createProfileConfig(config *measurement.MeasurementConfig) (*profileConfig,
 	if pc.provider, err = util.GetStringOrDefault(config.Params, "provider", config.ClusterFramework.GetClusterConfig().Provider); err != nil {
 		return nil, err
 	}
-	if pc.host, err = util.GetStringOrDefault(config.Params, "host", config.ClusterFramework.GetClusterConfig().GetMasterIp()); err != nil {
+	if pc.masterAccess, err = createMasterAccess(config, ""); err != nil {
+		return nil, err
+	}
+	if pc.namespace, err = util.GetStringOrDefault(config.Params, "namespace", metav1.NamespaceSystem); err != nil {
+		return nil, err
+	}
+	if pc.labelSelector, err = util.GetStringOrDefault(config.Params, "labelSelector", fmt.Sprintf("component=%s", pc.componentName)); err != nil {
+		return nil, err
+	}
+	if pc.serviceName, err = util.GetStringOrDefault(config.Params, "serviceName", ""); err != nil {
 		return nil, err
 	}
+	if pc.scheme, err = util.GetStringOrDefault(config.Params, "scheme", ""); err != nil {
+		return nil, err
+	}
+	if pc.port, err = util.GetIntOrDefault(config.Params, "port", defaultProfilePorts[pc.componentName]); err != nil {
+		return nil, err
+	}
+	if pc.path, err = util.GetStringOrDefault(config.Params, "path", defaultProfilePath); err != nil {
+		return nil, err
+	}
+	if pc.seconds, err = util.GetIntOrDefault(config.Params, "seconds", 0); err != nil {
+		return nil, err
+	}
+	// Currently length of the test is proportional to the cluster size.
+	// So by default we make the profiling frequency proportional to the cluster size.
+	// We may want to revisit ot adjust it in the future.
+	numNodes := config.ClusterFramework.GetClusterConfig().Nodes
+	defaultInterval := time.Duration(5+numNodes/250) * time.Minute
+	if pc.interval, err = util.GetDurationOrDefault(config.Params, "interval", defaultInterval); err != nil {
+		return nil, err
+	}
+	if pc.componentName != "kube-apiserver" && pc.port == 0 {
+		return nil, fmt.Errorf("port for component %v unknown", pc.componentName)
+	}
 	return pc, nil
-
 }
 
 type profileMeasurement struct {
 	name      string
+	kind      string
 	config    *profileConfig
+	lock      sync.Mutex
 	summaries []measurement.Summary
 	isRunning bool
 	stopCh    chan struct{}
 	wg        sync.WaitGroup
 }
 
-func (p *profileMeasurement) start(config *measurement.MeasurementConfig, profileKind string) error {
+// Execute gathers profiles of a given component.
+// Profiles are gathered periodically (with a given interval) between start and gather actions.
+// Component is accessed through the api server proxy - either the service proxy
+// (if serviceName is provided) or the pod proxy for every pod matching labelSelector
+// (component=<componentName> by default) in a given namespace.
+// If no such pod exists or none of them can be reached, profile is fetched from the master
+// using the master access method.
+func (p *profileMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "start":
+		if p.isRunning {
+			klog.Infof("%s: measurement already running", p)
+			return nil, nil
+		}
+		return nil, p.start(config)
+	case "gather":
+		p.stop()
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.summaries, nil
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (p *profileMeasurement) Dispose() {
+	p.stop()
+}
+
+// String returns string representation of this measurement.
+func (p *profileMeasurement) String() string {
+	return p.name
+}
+
+func (p *profileMeasurement) start(config *measurement.MeasurementConfig) error {
 	var err error
 	p.config, err = createProfileConfig(config)
 	if err != nil {
 		return err
 	}
-	p.config.kind = profileKind
 	p.summaries = make([]measurement.Summary, 0)
 	p.isRunning = true
 	p.stopCh = make(chan struct{})
 	p.wg.Add(1)
 
-	// Currently length of the test is proportional to the cluster size.
-	// So for now we make the profiling frequency proportional to the cluster size.
-	// We may want to revisit ot adjust it in the future.
-	numNodes := config.ClusterFramework.GetClusterConfig().Nodes
-	profileFrequency := time.Duration(5+numNodes/250) * time.Minute
-
 	go func() {
 		defer p.wg.Done()
 		for {
 			select {
 			case <-p.stopCh:
 				return
-			case <-time.After(profileFrequency):
-				profileSummary, err := p.gatherProfile(config.ClusterFramework.GetClientSets().GetClient())
+			case <-time.After(p.config.interval):
+				summaries, err := p.gatherProfile(config.ClusterFramework.GetClientSets().GetClient())
 				if err != nil {
-					klog.Errorf("failed to gather profile for %#v: %v", *p.config, err)
+					klog.Errorf("%s: failed to gather profile for %#v: %v", p, *p.config, err)
 					continue
 				}
-				if profileSummary != nil {
-					p.summaries = append(p.summaries, profileSummary)
-				}
+				p.lock.Lock()
+				p.summaries = append(p.summaries, summaries...)
+				p.lock.Unlock()
 			}
 		}
 	}()
@@ -118,138 +224,93 @@ func (p *profileMeasurement) stop() {
 	if !p.isRunning {
 		return
 	}
+	p.isRunning = false
 	close(p.stopCh)
 	p.wg.Wait()
 }
 
-func createMemoryProfileMeasurement() measurement.Measurement {
-	return &memoryProfileMeasurement{
-		measurement: profileMeasurement{
-			name: memoryProfileName,
-		},
-	}
-}
-
-type memoryProfileMeasurement struct {
-	measurement profileMeasurement
-}
-
-// Execute gathers memory profile of a given component.
-func (c *memoryProfileMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
-	action, err := util.GetString(config.Params, "action")
-	if err != nil {
-		return nil, err
+func (p *profileMeasurement) gatherProfile(c clientset.Interface) ([]measurement.Summary, error) {
+	profilePrefix := p.config.componentName + "_" + p.name
+	params := map[string]string{}
+	if p.kind == "profile" && p.config.seconds > 0 {
+		params["seconds"] = strconv.Itoa(p.config.seconds)
 	}
 
-	switch action {
-	case "start":
-		if c.measurement.isRunning {
-			klog.Infof("%s: measurement already running", c)
-			return nil, nil
+	if p.config.componentName == "kube-apiserver" {
+		request := c.CoreV1().RESTClient().Get().AbsPath(p.config.path + p.kind)
+		for key, value := range params {
+			request = request.Param(key, value)
 		}
-		return nil, c.measurement.start(config, "heap")
-	case "gather":
-		c.measurement.stop()
-		return c.measurement.summaries, nil
-	default:
-		return nil, fmt.Errorf("unknown action %v", action)
+		body, err := request.DoRaw()
+		if err != nil {
+			return nil, err
+		}
+		return []measurement.Summary{measurement.CreateSummary(profilePrefix, "pprof", string(body))}, nil
 	}
-}
-
-// Dispose cleans up after the measurement.
-func (*memoryProfileMeasurement) Dispose() {}
-
-// String returns string representation of this measurement.
-func (*memoryProfileMeasurement) String() string {
-	return memoryProfileName
-}
 
-func createCPUProfileMeasurement() measurement.Measurement {
-	return &cpuProfileMeasurement{
-		measurement: profileMeasurement{
-			name: cpuProfileName,
-		},
+	port := strconv.Itoa(p.config.port)
+	if p.config.serviceName != "" {
+		body, err := c.CoreV1().Services(p.config.namespace).ProxyGet(p.config.scheme, p.config.serviceName, port, p.config.path+p.kind, params).DoRaw()
+		if err != nil {
+			return nil, err
+		}
+		return []measurement.Summary{measurement.CreateSummary(profilePrefix, "pprof", string(body))}, nil
 	}
-}
 
-type cpuProfileMeasurement struct {
-	measurement profileMeasurement
-}
-
-// Execute gathers cpu profile of a given component.
-func (c *cpuProfileMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
-	action, err := util.GetString(config.Params, "action")
+	pods, err := c.CoreV1().Pods(p.config.namespace).List(metav1.ListOptions{LabelSelector: p.config.labelSelector})
 	if err != nil {
 		return nil, err
 	}
-
-	switch action {
-	case "start":
-		if c.measurement.isRunning {
-			klog.Infof("%s: measurement already running", c)
-			return nil, nil
-		}
-		return nil, c.measurement.start(config, "profile")
-	case "gather":
-		c.measurement.stop()
-		return c.measurement.summaries, nil
-	default:
-		return nil, fmt.Errorf("unknown action %v", action)
+	if len(pods.Items) == 0 {
+		return p.gatherProfileFromMaster(profilePrefix)
 	}
-}
-
-// Dispose cleans up after the measurement.
-func (*cpuProfileMeasurement) Dispose() {}
-
-// String returns string representation of this measurement.
-func (*cpuProfileMeasurement) String() string {
-	return cpuProfileName
-}
-
-func (p *profileMeasurement) gatherProfile(c clientset.Interface) (measurement.Summary, error) {
-	profilePrefix := p.config.componentName
-	switch {
-	case p.config.kind == "heap":
-		profilePrefix += "_MemoryProfile"
-	case strings.HasPrefix(p.config.kind, "profile"):
-		profilePrefix += "_CPUProfile"
-	default:
-		return nil, fmt.Errorf("unknown profile kind provided: %s", p.config.kind)
-	}
-
-	if p.config.componentName == "kube-apiserver" {
-		body, err := c.CoreV1().RESTClient().Get().AbsPath("/debug/pprof/" + p.config.kind).DoRaw()
+	var summaries []measurement.Summary
+	var errs []error
+	for _, pod := range pods.Items {
+		request := c.CoreV1().RESTClient().Get().
+			Namespace(p.config.namespace).
+			Resource("pods").
+			Name(utilnet.JoinSchemeNamePort(p.config.scheme, pod.Name, port)).
+			SubResource("proxy").
+			Suffix(p.config.path + p.kind)
+		for key, value := range params {
+			request = request.Param(key, value)
+		}
+		body, err := request.DoRaw()
 		if err != nil {
-			return nil, err
+			// Profiles of the other pods are still useful, so failing pod is only logged.
+			klog.Warningf("%s: fetching profile from pod %s error: %v", p, pod.Name, err)
+			errs = append(errs, fmt.Errorf("pod %s: %v", pod.Name, err))
+			continue
+		}
+		name := profilePrefix
+		if len(pods.Items) > 1 {
+			name = fmt.Sprintf("%s_%s", profilePrefix, pod.Name)
 		}
-		return measurement.CreateSummary(profilePrefix, "pprof", string(body)), err
+		summaries = append(summaries, measurement.CreateSummary(name, "pprof", string(body)))
 	}
+	if len(summaries) == 0 {
+		// Components running on the master may listen on localhost only (e.g. scheduler on gce),
+		// so they are unreachable through the pod proxy.
+		klog.Warningf("%s: fetching profiles from all pods failed (%v), fetching profile from master", p, errs)
+		return p.gatherProfileFromMaster(profilePrefix)
+	}
+	return summaries, nil
+}
 
-	profilePort, err := getPortForComponent(p.config.componentName)
-	if err != nil {
-		return nil, fmt.Errorf("profile gathering failed finding component port: %v", err)
+func (p *profileMeasurement) gatherProfileFromMaster(profilePrefix string) ([]measurement.Summary, error) {
+	query := ""
+	if p.kind == "profile" && p.config.seconds > 0 {
+		query = fmt.Sprintf("?seconds=%d", p.config.seconds)
 	}
-	// Get the profile data over SSH.
-	getCommand := fmt.Sprintf("curl -s localhost:%v/debug/pprof/%s", profilePort, p.config.kind)
-	sshResult, err := measurementutil.SSH(getCommand, p.config.host+":22", p.config.provider)
+	body, err := p.config.masterAccess.Request(p.config.componentName, "GET", p.config.port, p.config.path+p.kind+query)
 	if err != nil {
-		if p.config.provider == "gke" {
+		if p.config.provider == "gke" && p.config.masterAccess.String() == masteraccess.SSH {
 			// Only logging error for gke. SSHing to gke master is not supported.
-			klog.Errorf("%s: failed to execute curl command on master through SSH: %v", p.name, err)
+			klog.Errorf("%s: failed to fetch profile from master over %s: %v", p, p.config.masterAccess, err)
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to execute curl command on master through SSH: %v", err)
-	}
-
-	return measurement.CreateSummary(profilePrefix, "pprof", sshResult.Stdout), err
-}
-
-func getPortForComponent(componentName string) (int, error) {
-	switch componentName {
-	case "kube-scheduler":
-		return 10251, nil
-	case "kube-controller-manager":
-		return 10252, nil
+		return nil, fmt.Errorf("failed to fetch profile from master over %s: %v", p.config.masterAccess, err)
 	}
-	return -1, fmt.Errorf("port for component %v unknown", componentName)
+	return []measurement.Summary{measurement.CreateSummary(profilePrefix, "pprof", body)}, nil
 }
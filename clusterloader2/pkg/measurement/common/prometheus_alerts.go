@@ -0,0 +1,314 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	prometheusAlertsMeasurementName = "PrometheusAlerts"
+	defaultAlertsPollInterval       = 30 * time.Second
+	alertFiringState                = "firing"
+)
+
+func init() {
+	if err := measurement.Register(prometheusAlertsMeasurementName, createPrometheusAlertsMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", prometheusAlertsMeasurementName, err)
+	}
+}
+
+func createPrometheusAlertsMeasurement() measurement.Measurement {
+	return &prometheusAlertsMeasurement{}
+}
+
+type prometheusAlertsMeasurement struct {
+	lock      sync.Mutex
+	isRunning bool
+	stopCh    chan struct{}
+	startTime time.Time
+	client    prometheus.Client
+	tracker   *alertTracker
+}
+
+// Execute supports two actions:
+// - start - starts polling Prometheus alerts API every interval (30s by default).
+// - gather - stops polling and creates summary of alerts that fired in between
+//   (name, labels, when they started and stopped firing).
+//   The measurement fails if any of the fired alerts is not listed in allowedAlerts
+//   or is listed in deniedAlerts (at most one of them can be specified).
+//   If none of them is specified, every fired alert fails the measurement.
+//   Alerts firing already at start are reported, but they never fail the measurement
+//   unless they stop firing and fire again.
+// Pending alerts are ignored.
+func (p *prometheusAlertsMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	if config.PrometheusClient == nil {
+		klog.Warningf("%s: Prometheus is disabled, skipping the measurement!", p)
+		return nil, nil
+	}
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+	switch action {
+	case "start":
+		if p.isRunning {
+			klog.Infof("%s: measurement already running", p)
+			return nil, nil
+		}
+		interval, err := util.GetDurationOrDefault(config.Params, "interval", defaultAlertsPollInterval)
+		if err != nil {
+			return nil, err
+		}
+		p.start(config.PrometheusClient, interval)
+		return nil, nil
+	case "gather":
+		isViolation, err := newAlertViolationFunc(config.Params)
+		if err != nil {
+			return nil, err
+		}
+		return p.gather(config.Identifier, isViolation)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (p *prometheusAlertsMeasurement) Dispose() {
+	p.stop()
+}
+
+// String returns a string representation of the measurement.
+func (*prometheusAlertsMeasurement) String() string {
+	return prometheusAlertsMeasurementName
+}
+
+func (p *prometheusAlertsMeasurement) start(c prometheus.Client, interval time.Duration) {
+	klog.Infof("%s: starting polling alerts every %v", p, interval)
+	tracker := newAlertTracker()
+	// Alerts firing already at start are recorded, but they are not caused by the test.
+	if alerts, err := prometheus.GetAlerts(c); err != nil {
+		klog.Warningf("%s: error while getting initial alerts: %v", p, err)
+	} else {
+		tracker.init(alerts, time.Now())
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.isRunning = true
+	p.stopCh = make(chan struct{})
+	p.startTime = time.Now()
+	p.client = c
+	p.tracker = tracker
+	stopCh := p.stopCh
+	go func() {
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(interval):
+				p.poll(c, tracker)
+			}
+		}
+	}()
+}
+
+func (p *prometheusAlertsMeasurement) poll(c prometheus.Client, tracker *alertTracker) {
+	alerts, err := prometheus.GetAlerts(c)
+	if err != nil {
+		// This might happen if prometheus server is temporary down, log error but keep polling.
+		klog.Warningf("%s: error while getting alerts: %v", p, err)
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	tracker.update(alerts, time.Now())
+}
+
+func (p *prometheusAlertsMeasurement) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.isRunning {
+		p.isRunning = false
+		close(p.stopCh)
+	}
+}
+
+func (p *prometheusAlertsMeasurement) gather(identifier string, isViolation func(string) bool) ([]measurement.Summary, error) {
+	if !p.isRunning {
+		return nil, fmt.Errorf("measurement %s has not been started", p)
+	}
+	p.stop()
+	// Last poll catches alerts firing at the end of the measurement.
+	p.poll(p.client, p.tracker)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	summary := &prometheusAlertsSummary{
+		Start:  p.startTime,
+		End:    time.Now(),
+		Alerts: p.tracker.alerts,
+	}
+	var violations []string
+	for _, alert := range summary.Alerts {
+		alert.Duration = alert.FiringEnd.Sub(alert.FiringStart).String()
+		if !alert.FiringAtStart && isViolation(alert.Name) {
+			alert.Violation = true
+			violations = append(violations, alert.Name)
+		}
+	}
+	klog.Infof("%s: %d alerts fired, %d violations", p, len(summary.Alerts), len(violations))
+	content, err := util.PrettyPrintJSON(summary)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []measurement.Summary{
+		measurement.CreateSummary(fmt.Sprintf("%s_%s", prometheusAlertsMeasurementName, identifier), "json", content),
+	}
+	if len(violations) > 0 {
+		err = errors.NewMetricViolationError("prometheus alerts", fmt.Sprintf("fired alerts: %s", strings.Join(violations, ", ")))
+	}
+	return summaries, err
+}
+
+// newAlertViolationFunc returns function deciding whether fired alert with the given name is a violation.
+func newAlertViolationFunc(params map[string]interface{}) (func(string) bool, error) {
+	allowed, err := util.GetStringArrayOrDefault(params, "allowedAlerts", nil)
+	if err != nil {
+		return nil, err
+	}
+	denied, err := util.GetStringArrayOrDefault(params, "deniedAlerts", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowed) > 0 && len(denied) > 0 {
+		return nil, fmt.Errorf("at most one of allowedAlerts and deniedAlerts can be specified")
+	}
+	if len(denied) > 0 {
+		deniedSet := toSet(denied)
+		return func(name string) bool { return deniedSet[name] }, nil
+	}
+	allowedSet := toSet(allowed)
+	return func(name string) bool { return !allowedSet[name] }, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+type prometheusAlertsSummary struct {
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Alerts []*firedAlert `json:"alerts"`
+}
+
+// firedAlert represents a single period in which the alert was firing.
+type firedAlert struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	// ActiveAt is the time when the alert became pending (as reported by Prometheus).
+	ActiveAt time.Time `json:"activeAt"`
+	// FiringStart and FiringEnd are the times of the first and the last poll in which the alert was firing.
+	FiringStart time.Time `json:"firingStart"`
+	FiringEnd   time.Time `json:"firingEnd"`
+	// FiringAtStart is set if the alert was already firing when the measurement started.
+	// Such alerts are never violations.
+	FiringAtStart bool   `json:"firingAtStart"`
+	Duration      string `json:"duration"`
+	Violation     bool   `json:"violation"`
+}
+
+// alertTracker tracks periods in which alerts were firing.
+// If the alert stops firing and fires again, a new period is recorded.
+type alertTracker struct {
+	// active maps keys of the currently firing alerts to their periods.
+	active map[string]*firedAlert
+	// initial contains keys of the alerts firing since the tracking started.
+	initial map[string]bool
+	alerts  []*firedAlert
+}
+
+func newAlertTracker() *alertTracker {
+	return &alertTracker{active: make(map[string]*firedAlert), initial: make(map[string]bool)}
+}
+
+// init records alerts firing when the tracking starts.
+// They are marked as firing at start until they stop firing for the first time.
+func (t *alertTracker) init(alerts []prometheus.Alert, now time.Time) {
+	for _, alert := range alerts {
+		if alert.State == alertFiringState {
+			t.initial[alertKey(alert.Labels)] = true
+		}
+	}
+	t.update(alerts, now)
+}
+
+func (t *alertTracker) update(alerts []prometheus.Alert, now time.Time) {
+	firing := make(map[string]*firedAlert)
+	for _, alert := range alerts {
+		if alert.State != alertFiringState {
+			continue
+		}
+		key := alertKey(alert.Labels)
+		if fired, ok := t.active[key]; ok {
+			fired.FiringEnd = now
+			firing[key] = fired
+			continue
+		}
+		fired := &firedAlert{
+			Name:          alert.Labels["alertname"],
+			Labels:        alert.Labels,
+			ActiveAt:      alert.ActiveAt,
+			FiringStart:   now,
+			FiringEnd:     now,
+			FiringAtStart: t.initial[key],
+		}
+		klog.Infof("%s: alert %s started firing", prometheusAlertsMeasurementName, key)
+		t.alerts = append(t.alerts, fired)
+		firing[key] = fired
+	}
+	for key := range t.initial {
+		if _, ok := firing[key]; !ok {
+			delete(t.initial, key)
+		}
+	}
+	t.active = firing
+}
+
+// alertKey identifies the alert by its labels.
+func alertKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
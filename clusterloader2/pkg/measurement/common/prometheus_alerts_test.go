@@ -0,0 +1,123 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
+)
+
+// fakeAlertsClient serves the given alerts from the alerts API.
+type fakeAlertsClient struct {
+	lock   sync.Mutex
+	alerts []prometheus.Alert
+}
+
+func (f *fakeAlertsClient) Get(_ string, _ map[string]string) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return json.Marshal(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"alerts": f.alerts},
+	})
+}
+
+func (f *fakeAlertsClient) setAlerts(alerts ...prometheus.Alert) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.alerts = alerts
+}
+
+func TestAlertTracker(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+	targetDown := prometheus.Alert{Labels: map[string]string{"alertname": "TargetDown", "job": "a"}, State: "firing", ActiveAt: start}
+	pending := prometheus.Alert{Labels: map[string]string{"alertname": "APIServerHighRequestLatency"}, State: "pending"}
+
+	tracker := newAlertTracker()
+	tracker.update([]prometheus.Alert{targetDown, pending}, at(1))
+	tracker.update([]prometheus.Alert{targetDown}, at(2))
+	tracker.update(nil, at(3))
+	tracker.update([]prometheus.Alert{targetDown}, at(4))
+
+	assert.Equal(t, []*firedAlert{
+		{Name: "TargetDown", Labels: targetDown.Labels, ActiveAt: start, FiringStart: at(1), FiringEnd: at(2)},
+		{Name: "TargetDown", Labels: targetDown.Labels, ActiveAt: start, FiringStart: at(4), FiringEnd: at(4)},
+	}, tracker.alerts)
+}
+
+func TestAlertTrackerInitialAlerts(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+	targetDown := prometheus.Alert{Labels: map[string]string{"alertname": "TargetDown"}, State: "firing", ActiveAt: start}
+
+	tracker := newAlertTracker()
+	tracker.init([]prometheus.Alert{targetDown}, at(0))
+	tracker.update([]prometheus.Alert{targetDown}, at(1))
+	tracker.update(nil, at(2))
+	tracker.update([]prometheus.Alert{targetDown}, at(3))
+
+	assert.Equal(t, []*firedAlert{
+		{Name: "TargetDown", Labels: targetDown.Labels, ActiveAt: start, FiringStart: at(0), FiringEnd: at(1), FiringAtStart: true},
+		{Name: "TargetDown", Labels: targetDown.Labels, ActiveAt: start, FiringStart: at(3), FiringEnd: at(3)},
+	}, tracker.alerts)
+}
+
+func TestPrometheusAlertsGather(t *testing.T) {
+	targetDown := prometheus.Alert{Labels: map[string]string{"alertname": "TargetDown"}, State: "firing"}
+	apiserverDown := prometheus.Alert{Labels: map[string]string{"alertname": "KubeAPIDown"}, State: "firing"}
+	client := &fakeAlertsClient{}
+	client.setAlerts(targetDown)
+
+	p := &prometheusAlertsMeasurement{}
+	p.start(client, time.Hour)
+	client.setAlerts(targetDown, apiserverDown)
+	summaries, err := p.gather("test", func(string) bool { return true })
+
+	assert.Len(t, summaries, 1)
+	assert.True(t, errors.IsMetricViolationError(err), "got error: %v", err)
+	assert.Contains(t, err.Error(), "KubeAPIDown")
+	assert.NotContains(t, err.Error(), "TargetDown")
+}
+
+func TestAlertViolationFunc(t *testing.T) {
+	isViolation, err := newAlertViolationFunc(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.True(t, isViolation("TargetDown"))
+
+	isViolation, err = newAlertViolationFunc(map[string]interface{}{"allowedAlerts": []interface{}{"TargetDown"}})
+	assert.NoError(t, err)
+	assert.False(t, isViolation("TargetDown"))
+	assert.True(t, isViolation("APIServerHighRequestLatency"))
+
+	isViolation, err = newAlertViolationFunc(map[string]interface{}{"deniedAlerts": []interface{}{"TargetDown"}})
+	assert.NoError(t, err)
+	assert.True(t, isViolation("TargetDown"))
+	assert.False(t, isViolation("APIServerHighRequestLatency"))
+
+	_, err = newAlertViolationFunc(map[string]interface{}{
+		"allowedAlerts": []interface{}{"TargetDown"},
+		"deniedAlerts":  []interface{}{"APIServerHighRequestLatency"},
+	})
+	assert.Error(t, err)
+}
@@ -31,7 +31,12 @@ import (
 )
 
 const (
-	resourceUsageMetricName = "ResourceUsageSummary"
+	resourceUsageMetricName      = "ResourceUsageSummary"
+	nodeResourceUsageSummaryName = "NodeResourceUsageSummary"
+	containerHealthSummaryName   = "ContainerHealthSummary"
+	// allNodesConstraintName is the name of the node constraint applied to nodes without their own constraint.
+	// It can't collide with any node name.
+	allNodesConstraintName = "*"
 )
 
 func init() {
@@ -42,13 +47,17 @@ func init() {
 
 func createResourceUsageMetricMeasurement() measurement.Measurement {
 	return &resourceUsageMetricMeasurement{
-		resourceConstraints: make(map[string]*measurementutil.ResourceConstraint),
+		resourceConstraints:     make(map[string]*measurementutil.ResourceConstraint),
+		nodeResourceConstraints: make(map[string]*measurementutil.ResourceConstraint),
 	}
 }
 
 type resourceUsageMetricMeasurement struct {
 	gatherer            *gatherers.ContainerResourceGatherer
 	resourceConstraints map[string]*measurementutil.ResourceConstraint
+	// nodeResourceConstraints are keyed by node name or allNodesConstraintName.
+	nodeResourceConstraints map[string]*measurementutil.ResourceConstraint
+	collectNodeUsage        bool
 }
 
 // Execute supports two actions:
@@ -66,7 +75,7 @@ func (e *resourceUsageMetricMeasurement) Execute(config *measurement.Measurement
 		if err != nil {
 			return nil, err
 		}
-		host, err := util.GetStringOrDefault(config.Params, "host", config.ClusterFramework.GetClusterConfig().GetMasterIp())
+		masterAccess, err := createMasterAccess(config, "")
 		if err != nil {
 			return nil, err
 		}
@@ -74,23 +83,39 @@ func (e *resourceUsageMetricMeasurement) Execute(config *measurement.Measurement
 		if err != nil {
 			return nil, err
 		}
+		namespace, err := util.GetStringOrDefault(config.Params, "namespace", "")
+		if err != nil {
+			return nil, err
+		}
+		labelSelector, err := util.GetStringOrDefault(config.Params, "labelSelector", "")
+		if err != nil {
+			return nil, err
+		}
+		if e.collectNodeUsage, err = util.GetBoolOrDefault(config.Params, "collectNodeUsage", false); err != nil {
+			return nil, err
+		}
 		constraintsPath, err := util.GetStringOrDefault(config.Params, "resourceConstraints", "")
 		if err != nil {
 			return nil, err
 		}
 		if constraintsPath != "" {
-			mapping := make(map[string]interface{})
-			mapping["Nodes"] = config.ClusterFramework.GetClusterConfig().Nodes
-			if err = config.TemplateProvider.TemplateInto(constraintsPath, mapping, &e.resourceConstraints); err != nil {
+			if e.resourceConstraints, err = readResourceConstraints(config, constraintsPath); err != nil {
 				return nil, fmt.Errorf("resource constraints reading error: %v", err)
 			}
-			for _, constraint := range e.resourceConstraints {
-				if constraint.CPUConstraint == 0 {
-					constraint.CPUConstraint = math.MaxFloat64
-				}
-				if constraint.MemoryConstraint == 0 {
-					constraint.MemoryConstraint = math.MaxUint64
-				}
+			if err = validateContainerConstraints(e.resourceConstraints); err != nil {
+				return nil, err
+			}
+		}
+		nodeConstraintsPath, err := util.GetStringOrDefault(config.Params, "nodeResourceConstraints", "")
+		if err != nil {
+			return nil, err
+		}
+		if nodeConstraintsPath != "" {
+			if !e.collectNodeUsage {
+				return nil, fmt.Errorf("nodeResourceConstraints require collectNodeUsage to be enabled")
+			}
+			if e.nodeResourceConstraints, err = readResourceConstraints(config, nodeConstraintsPath); err != nil {
+				return nil, fmt.Errorf("node resource constraints reading error: %v", err)
 			}
 		}
 		var nodesSet gatherers.NodesSet
@@ -99,14 +124,26 @@ func (e *resourceUsageMetricMeasurement) Execute(config *measurement.Measurement
 			nodesSet = gatherers.MasterNodes
 		case "masteranddns":
 			nodesSet = gatherers.MasterAndDNSNodes
+		case "pods":
+			nodesSet = gatherers.PodNodes
+		case "":
+			// By default, nodes running the selected pods are tracked if pods are selected.
+			if labelSelector != "" {
+				nodesSet = gatherers.PodNodes
+			} else {
+				nodesSet = gatherers.AllNodes
+			}
 		default:
 			nodesSet = gatherers.AllNodes
 		}
 
 		klog.Infof("%s: starting resource usage collecting...", e)
-		e.gatherer, err = gatherers.NewResourceUsageGatherer(config.ClusterFramework.GetClientSets().GetClient(), host, provider, gatherers.ResourceGathererOptions{
+		e.gatherer, err = gatherers.NewResourceUsageGatherer(config.ClusterFramework.GetClientSets().GetClient(), masterAccess, gatherers.ResourceGathererOptions{
 			InKubemark:                  strings.ToLower(provider) == "kubemark",
 			Nodes:                       nodesSet,
+			Namespace:                   namespace,
+			LabelSelector:               labelSelector,
+			CollectNodeUsage:            e.collectNodeUsage,
 			ResourceDataGatheringPeriod: 60 * time.Second,
 			ProbeDuration:               15 * time.Second,
 			PrintVerboseLogs:            false,
@@ -131,7 +168,24 @@ func (e *resourceUsageMetricMeasurement) Execute(config *measurement.Measurement
 			return nil, err
 		}
 		resourceSummary := measurement.CreateSummary(resourceUsageMetricName, "json", content)
-		return []measurement.Summary{resourceSummary}, e.verifySummary(summary)
+
+		healthSummary := e.gatherer.SummarizeContainerHealth()
+		content, err = util.PrettyPrintJSON(healthSummary)
+		if err != nil {
+			return nil, err
+		}
+		summaries := []measurement.Summary{resourceSummary, measurement.CreateSummary(containerHealthSummaryName, "json", content)}
+
+		var nodeSummary *gatherers.NodeResourceUsageSummary
+		if e.collectNodeUsage {
+			nodeSummary = e.gatherer.SummarizeNodeUsage([]int{50, 90, 99, 100})
+			content, err = util.PrettyPrintJSON(nodeSummary)
+			if err != nil {
+				return nil, err
+			}
+			summaries = append(summaries, measurement.CreateSummary(nodeResourceUsageSummaryName, "json", content))
+		}
+		return summaries, e.verifySummary(summary, nodeSummary, healthSummary)
 
 	default:
 		return nil, fmt.Errorf("unknown action %v", action)
@@ -150,7 +204,7 @@ func (*resourceUsageMetricMeasurement) String() string {
 	return resourceUsageMetricName
 }
 
-func (e *resourceUsageMetricMeasurement) verifySummary(summary *gatherers.ResourceUsageSummary) error {
+func (e *resourceUsageMetricMeasurement) verifySummary(summary *gatherers.ResourceUsageSummary, nodeSummary *gatherers.NodeResourceUsageSummary, healthSummary *gatherers.ContainerHealthSummary) error {
 	violatedConstraints := make([]string, 0)
 	for _, containerSummary := range summary.Get("99") {
 		containerName := strings.Split(containerSummary.Name, "/")[1]
@@ -177,6 +231,18 @@ func (e *resourceUsageMetricMeasurement) verifySummary(summary *gatherers.Resour
 			}
 		}
 	}
+	if nodeSummary != nil {
+		violatedConstraints = append(violatedConstraints, e.verifyNodeSummary(nodeSummary)...)
+	}
+	if healthSummary != nil {
+		for _, failure := range healthSummary.Failures {
+			if failure.OOMKilled > 0 {
+				violatedConstraints = append(violatedConstraints, fmt.Sprintf("container %v was OOM killed %d times", failure.Name, failure.OOMKilled))
+			} else if failure.Restarts > 0 {
+				klog.Warningf("%s: container %v restarted %d times", e, failure.Name, failure.Restarts)
+			}
+		}
+	}
 	if len(violatedConstraints) > 0 {
 		for i := range violatedConstraints {
 			klog.Errorf("%s: violation: %s", e, violatedConstraints[i])
@@ -185,3 +251,81 @@ func (e *resourceUsageMetricMeasurement) verifySummary(summary *gatherers.Resour
 	}
 	return nil
 }
+
+// verifyNodeSummary checks node resource usage against the constraint of the node
+// (or the "*" constraint if node has no constraint on its own).
+func (e *resourceUsageMetricMeasurement) verifyNodeSummary(nodeSummary *gatherers.NodeResourceUsageSummary) []string {
+	violatedConstraints := make([]string, 0)
+	for _, node := range nodeSummary.Get("99") {
+		constraint, ok := e.nodeResourceConstraints[node.Name]
+		if !ok {
+			if constraint, ok = e.nodeResourceConstraints[allNodesConstraintName]; !ok {
+				continue
+			}
+		}
+		if node.Cpu > constraint.CPUConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is using %v/%v CPU", node.Name, node.Cpu, constraint.CPUConstraint))
+		}
+		if node.Mem > constraint.MemoryConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is using %v/%v MB of memory",
+				node.Name, float64(node.Mem)/(1024*1024), float64(constraint.MemoryConstraint)/(1024*1024)))
+		}
+		if node.DiskRead > constraint.DiskReadConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is reading %v/%v B/s from disk", node.Name, node.DiskRead, constraint.DiskReadConstraint))
+		}
+		if node.DiskWrite > constraint.DiskWriteConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is writing %v/%v B/s to disk", node.Name, node.DiskWrite, constraint.DiskWriteConstraint))
+		}
+		if node.NetworkRx > constraint.NetworkRxConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is receiving %v/%v B/s over network", node.Name, node.NetworkRx, constraint.NetworkRxConstraint))
+		}
+		if node.NetworkTx > constraint.NetworkTxConstraint {
+			violatedConstraints = append(violatedConstraints, fmt.Sprintf("node %v is transmitting %v/%v B/s over network", node.Name, node.NetworkTx, constraint.NetworkTxConstraint))
+		}
+	}
+	return violatedConstraints
+}
+
+// readResourceConstraints reads constraints from the templated file.
+// Constraints that are not specified are set to the maximal value.
+func readResourceConstraints(config *measurement.MeasurementConfig, path string) (map[string]*measurementutil.ResourceConstraint, error) {
+	mapping := make(map[string]interface{})
+	mapping["Nodes"] = config.ClusterFramework.GetClusterConfig().Nodes
+	constraints := make(map[string]*measurementutil.ResourceConstraint)
+	if err := config.TemplateProvider.TemplateInto(path, mapping, &constraints); err != nil {
+		return nil, err
+	}
+	for _, constraint := range constraints {
+		if constraint.CPUConstraint == 0 {
+			constraint.CPUConstraint = math.MaxFloat64
+		}
+		if constraint.MemoryConstraint == 0 {
+			constraint.MemoryConstraint = math.MaxUint64
+		}
+		if constraint.DiskReadConstraint == 0 {
+			constraint.DiskReadConstraint = math.MaxFloat64
+		}
+		if constraint.DiskWriteConstraint == 0 {
+			constraint.DiskWriteConstraint = math.MaxFloat64
+		}
+		if constraint.NetworkRxConstraint == 0 {
+			constraint.NetworkRxConstraint = math.MaxFloat64
+		}
+		if constraint.NetworkTxConstraint == 0 {
+			constraint.NetworkTxConstraint = math.MaxFloat64
+		}
+	}
+	return constraints, nil
+}
+
+// validateContainerConstraints checks that container constraints don't specify
+// disk and network constraints, which are verified for nodes only.
+func validateContainerConstraints(constraints map[string]*measurementutil.ResourceConstraint) error {
+	for name, constraint := range constraints {
+		if constraint.DiskReadConstraint != math.MaxFloat64 || constraint.DiskWriteConstraint != math.MaxFloat64 ||
+			constraint.NetworkRxConstraint != math.MaxFloat64 || constraint.NetworkTxConstraint != math.MaxFloat64 {
+			return fmt.Errorf("container %s: disk and network constraints can be specified in nodeResourceConstraints only", name)
+		}
+	}
+	return nil
+}
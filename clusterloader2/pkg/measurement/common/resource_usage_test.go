@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/gatherers"
+)
+
+func unlimitedConstraint() *measurementutil.ResourceConstraint {
+	return &measurementutil.ResourceConstraint{
+		CPUConstraint:       math.MaxFloat64,
+		MemoryConstraint:    math.MaxUint64,
+		DiskReadConstraint:  math.MaxFloat64,
+		DiskWriteConstraint: math.MaxFloat64,
+		NetworkRxConstraint: math.MaxFloat64,
+		NetworkTxConstraint: math.MaxFloat64,
+	}
+}
+
+func TestValidateContainerConstraints(t *testing.T) {
+	constraint := unlimitedConstraint()
+	constraint.CPUConstraint = 1
+	assert.NoError(t, validateContainerConstraints(map[string]*measurementutil.ResourceConstraint{"kube-proxy": constraint}))
+
+	constraint = unlimitedConstraint()
+	constraint.DiskWriteConstraint = 1024
+	assert.Error(t, validateContainerConstraints(map[string]*measurementutil.ResourceConstraint{"kube-proxy": constraint}))
+}
+
+func TestVerifyNodeSummary(t *testing.T) {
+	allNodes := unlimitedConstraint()
+	allNodes.NetworkRxConstraint = 100
+	nodeA := unlimitedConstraint()
+	nodeA.DiskReadConstraint = 10
+	e := &resourceUsageMetricMeasurement{
+		// Container constraints must not be applied to nodes.
+		resourceConstraints: map[string]*measurementutil.ResourceConstraint{"node-b": {}},
+		nodeResourceConstraints: map[string]*measurementutil.ResourceConstraint{
+			allNodesConstraintName: allNodes,
+			"node-a":               nodeA,
+		},
+	}
+	summary := &gatherers.NodeResourceUsageSummary{
+		"99": {
+			{Name: "node-a", DiskRead: 20, NetworkRx: 200},
+			{Name: "node-b", DiskRead: 20, NetworkRx: 200},
+			{Name: "node-c", DiskRead: 20, NetworkRx: 50},
+		},
+	}
+	assert.Equal(t, []string{
+		"node node-a is reading 20/10 B/s from disk",
+		"node node-b is receiving 200/100 B/s over network",
+	}, e.verifyNodeSummary(summary))
+}
@@ -17,7 +17,6 @@ limitations under the License.
 package common
 
 import (
-	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -32,13 +31,13 @@ import (
 	"k8s.io/kubernetes/pkg/util/system"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
 	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 )
 
 const (
 	schedulerLatencyMetricName  = "SchedulingMetrics"
 	schedulingLatencyMetricName = model.LabelValue(schedulermetric.SchedulerSubsystem + "_" + schedulermetric.SchedulingLatencyName)
-	singleRestCallTimeout       = 5 * time.Minute
 )
 
 func init() {
@@ -65,11 +64,18 @@ func (s *schedulerLatencyMeasurement) Execute(config *measurement.MeasurementCon
 	if err != nil {
 		return nil, err
 	}
-	masterIP, err := util.GetStringOrDefault(config.Params, "masterIP", config.ClusterFramework.GetClusterConfig().GetMasterIp())
+	c := config.ClusterFramework.GetClientSets().GetClient()
+	// If master is registered, scheduler is accessed through the api server proxy by default.
+	// Otherwise, we fall back to the method chosen based on the provider.
+	masterRegistered, err := isMasterRegistered(c)
 	if err != nil {
 		return nil, err
 	}
-	masterName, err := util.GetStringOrDefault(config.Params, "masterName", config.ClusterFramework.GetClusterConfig().MasterName)
+	defaultAccessMethod := ""
+	if masterRegistered {
+		defaultAccessMethod = masteraccess.Proxy
+	}
+	access, err := createMasterAccess(config, defaultAccessMethod)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +83,9 @@ func (s *schedulerLatencyMeasurement) Execute(config *measurement.MeasurementCon
 	switch action {
 	case "reset":
 		klog.Infof("%s: resetting latency metrics in scheduler...", s)
-		return nil, s.resetSchedulerMetrics(config.ClusterFramework.GetClientSets().GetClient(), masterIP, provider, masterName)
+		return nil, s.resetSchedulerMetrics(access, provider)
 	case "gather":
-		return s.getSchedulingLatency(config.ClusterFramework.GetClientSets().GetClient(), masterIP, provider, masterName)
+		return s.getSchedulingLatency(access, provider)
 	default:
 		return nil, fmt.Errorf("unknown action %v", action)
 	}
@@ -93,8 +99,8 @@ func (*schedulerLatencyMeasurement) String() string {
 	return schedulerLatencyMetricName
 }
 
-func (s *schedulerLatencyMeasurement) resetSchedulerMetrics(c clientset.Interface, host, provider, masterName string) error {
-	_, err := s.sendRequestToScheduler(c, "DELETE", host, provider, masterName)
+func (s *schedulerLatencyMeasurement) resetSchedulerMetrics(access masteraccess.MasterAccess, provider string) error {
+	_, err := s.sendRequestToScheduler(access, "DELETE", provider)
 	if err != nil {
 		return err
 	}
@@ -102,9 +108,9 @@ func (s *schedulerLatencyMeasurement) resetSchedulerMetrics(c clientset.Interfac
 }
 
 // Retrieves scheduler latency metrics.
-func (s *schedulerLatencyMeasurement) getSchedulingLatency(c clientset.Interface, host, provider, masterName string) ([]measurement.Summary, error) {
+func (s *schedulerLatencyMeasurement) getSchedulingLatency(access masteraccess.MasterAccess, provider string) ([]measurement.Summary, error) {
 	result := schedulingMetrics{}
-	data, err := s.sendRequestToScheduler(c, "GET", host, provider, masterName)
+	data, err := s.sendRequestToScheduler(access, "GET", provider)
 	if err != nil {
 		return nil, err
 	}
@@ -150,57 +156,30 @@ func (s *schedulerLatencyMeasurement) getSchedulingLatency(c clientset.Interface
 }
 
 // Sends request to kube scheduler metrics
-func (s *schedulerLatencyMeasurement) sendRequestToScheduler(c clientset.Interface, op, host, provider, masterName string) (string, error) {
+func (s *schedulerLatencyMeasurement) sendRequestToScheduler(access masteraccess.MasterAccess, op, provider string) (string, error) {
 	opUpper := strings.ToUpper(op)
 	if opUpper != "GET" && opUpper != "DELETE" {
 		return "", fmt.Errorf("unknown REST request")
 	}
 
+	if provider == "gke" && access.String() == masteraccess.SSH {
+		klog.Infof("%s: not grabbing scheduler metrics through master SSH: unsupported for gke", s)
+		return "", nil
+	}
+	return access.Request("kube-scheduler", opUpper, ports.InsecureSchedulerPort, "/metrics")
+}
+
+func isMasterRegistered(c clientset.Interface) (bool, error) {
 	nodes, err := c.CoreV1().Nodes().List(metav1.ListOptions{})
 	if err != nil {
-		return "", err
+		return false, err
 	}
-
-	var masterRegistered = false
 	for _, node := range nodes.Items {
 		if system.IsMasterNode(node.Name) {
-			masterRegistered = true
-		}
-	}
-
-	var responseText string
-	if masterRegistered {
-		ctx, cancel := context.WithTimeout(context.Background(), singleRestCallTimeout)
-		defer cancel()
-
-		body, err := c.CoreV1().RESTClient().Verb(opUpper).
-			Context(ctx).
-			Namespace(metav1.NamespaceSystem).
-			Resource("pods").
-			Name(fmt.Sprintf("kube-scheduler-%v:%v", masterName, ports.InsecureSchedulerPort)).
-			SubResource("proxy").
-			Suffix("metrics").
-			Do().Raw()
-
-		if err != nil {
-			return "", err
-		}
-		responseText = string(body)
-	} else {
-		// If master is not registered fall back to old method of using SSH.
-		if provider == "gke" {
-			klog.Infof("%s: not grabbing scheduler metrics through master SSH: unsupported for gke", s)
-			return "", nil
-		}
-
-		cmd := "curl -X " + opUpper + " http://localhost:10251/metrics"
-		sshResult, err := measurementutil.SSH(cmd, host+":22", provider)
-		if err != nil || sshResult.Code != 0 {
-			return "", fmt.Errorf("unexpected error (code: %d) in ssh connection to master: %#v", sshResult.Code, err)
+			return true, nil
 		}
-		responseText = sshResult.Stdout
 	}
-	return responseText, nil
+	return false, nil
 }
 
 type schedulingMetrics struct {
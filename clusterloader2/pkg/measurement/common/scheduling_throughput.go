@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
 	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
@@ -32,6 +34,7 @@ import (
 
 const (
 	schedulingThroughputMeasurementName = "SchedulingThroughput"
+	schedulingThroughputSeriesName      = "SchedulingThroughputSeries"
 )
 
 func init() {
@@ -45,16 +48,20 @@ func createSchedulingThroughputMeasurement() measurement.Measurement {
 }
 
 type schedulingThroughputMeasurement struct {
-	schedulingThroughputs []float64
-	isRunning             bool
-	stopCh                chan struct{}
+	lock      sync.Mutex
+	samples   []schedulingThroughputSample
+	threshold float64
+	isRunning bool
+	stopCh    chan struct{}
 }
 
 // Execute supports two actions:
 // - start - starts the pods scheduling observation.
 //   Pods can be specified by field and/or label selectors.
 //   If namespace is not passed by parameter, all-namespace scope is assumed.
-// - gather - creates summary for observed values.
+// - gather - creates summary for observed values and the series of per-interval samples.
+//   If threshold is passed by parameter, the measurement fails when the minimal
+//   throughput of the busy intervals is below it (see minBusyThroughput).
 func (s *schedulingThroughputMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
 	action, err := util.GetString(config.Params, "action")
 	if err != nil {
@@ -82,6 +89,11 @@ func (s *schedulingThroughputMeasurement) Execute(config *measurement.Measuremen
 		s.stopCh = make(chan struct{})
 		return nil, s.start(config.ClusterFramework.GetClientSets().GetClient(), namespace, labelSelector, fieldSelector)
 	case "gather":
+		threshold, err := util.GetFloat64OrDefault(config.Params, "threshold", 0)
+		if err != nil {
+			return nil, err
+		}
+		s.threshold = threshold
 		return s.gather()
 	default:
 		return nil, fmt.Errorf("unknown action %v", action)
@@ -118,7 +130,12 @@ func (s *schedulingThroughputMeasurement) start(clientSet clientset.Interface, n
 				pods := ps.List()
 				podsStatus := measurementutil.ComputePodsStartupStatus(pods, 0)
 				throughput := float64(podsStatus.Scheduled-lastScheduledCount) / float64(defaultWaitForPodsInterval/time.Second)
-				s.schedulingThroughputs = append(s.schedulingThroughputs, throughput)
+				s.addSample(schedulingThroughputSample{
+					Time:       time.Now(),
+					Scheduled:  podsStatus.Scheduled,
+					Waiting:    podsStatus.Waiting,
+					Throughput: throughput,
+				})
 				lastScheduledCount = podsStatus.Scheduled
 				klog.Infof("%v: %s: %d pods scheduled", s, selectorsString, lastScheduledCount)
 			}
@@ -127,6 +144,12 @@ func (s *schedulingThroughputMeasurement) start(clientSet clientset.Interface, n
 	return nil
 }
 
+func (s *schedulingThroughputMeasurement) addSample(sample schedulingThroughputSample) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.samples = append(s.samples, sample)
+}
+
 func (s *schedulingThroughputMeasurement) gather() ([]measurement.Summary, error) {
 	if !s.isRunning {
 		klog.Errorf("%s: measurementis nor running", s)
@@ -135,24 +158,78 @@ func (s *schedulingThroughputMeasurement) gather() ([]measurement.Summary, error
 	s.stop()
 	klog.Infof("%s: gathering data", s)
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	throughputs := make([]float64, 0, len(s.samples))
+	for i := range s.samples {
+		throughputs = append(throughputs, s.samples[i].Throughput)
+	}
 	throughputSummary := &schedulingThroughput{}
-	if length := len(s.schedulingThroughputs); length > 0 {
-		sort.Float64s(s.schedulingThroughputs)
+	maxThroughput := 0.0
+	if length := len(throughputs); length > 0 {
+		sort.Float64s(throughputs)
 		sum := 0.0
-		for i := range s.schedulingThroughputs {
-			sum += s.schedulingThroughputs[i]
+		for i := range throughputs {
+			sum += throughputs[i]
 		}
+		maxThroughput = throughputs[length-1]
 		throughputSummary.Average = sum / float64(length)
-		throughputSummary.Perc50 = s.schedulingThroughputs[int(math.Ceil(float64(length*50)/100))-1]
-		throughputSummary.Perc90 = s.schedulingThroughputs[int(math.Ceil(float64(length*90)/100))-1]
-		throughputSummary.Perc99 = s.schedulingThroughputs[int(math.Ceil(float64(length*99)/100))-1]
+		throughputSummary.Perc50 = throughputs[int(math.Ceil(float64(length*50)/100))-1]
+		throughputSummary.Perc90 = throughputs[int(math.Ceil(float64(length*90)/100))-1]
+		throughputSummary.Perc99 = throughputs[int(math.Ceil(float64(length*99)/100))-1]
 	}
 	content, err := util.PrettyPrintJSON(throughputSummary)
 	if err != nil {
 		return nil, err
 	}
 	summary := measurement.CreateSummary(schedulingThroughputMeasurementName, "json", content)
-	return []measurement.Summary{summary}, nil
+
+	minBusy, hasBusy := minBusyThroughput(s.samples)
+	series := &schedulingThroughputSeries{
+		Interval: defaultWaitForPodsInterval.String(),
+		Max:      maxThroughput,
+		MinBusy:  minBusy,
+		Samples:  s.samples,
+	}
+	seriesContent, err := util.PrettyPrintJSON(series)
+	if err != nil {
+		return nil, err
+	}
+	seriesSummary := measurement.CreateSummary(schedulingThroughputSeriesName, "json", seriesContent)
+	summaries := []measurement.Summary{summary, seriesSummary}
+
+	if s.threshold <= 0 {
+		return summaries, nil
+	}
+	if !hasBusy {
+		klog.Warningf("%s: no interval in which pods were continuously waiting to be scheduled, threshold not verified", s)
+		return summaries, nil
+	}
+	if minBusy < s.threshold {
+		err = errors.NewMetricViolationError(
+			"scheduler throughput",
+			fmt.Sprintf("minimal throughput %v of the busy intervals is below threshold %v", minBusy, s.threshold))
+		klog.Errorf("%s: %v", s, err)
+		return summaries, err
+	}
+	return summaries, nil
+}
+
+// minBusyThroughput returns the minimal throughput of the busy intervals, i.e. the ones
+// in which pods were waiting to be scheduled both at the beginning and at the end.
+// Other intervals (e.g. before pods are created or after all of them are scheduled)
+// don't tell anything about the scheduler performance. Returns false if there is no busy interval.
+func minBusyThroughput(samples []schedulingThroughputSample) (float64, bool) {
+	minThroughput, found := 0.0, false
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1].Waiting == 0 || samples[i].Waiting == 0 {
+			continue
+		}
+		if !found || samples[i].Throughput < minThroughput {
+			minThroughput, found = samples[i].Throughput, true
+		}
+	}
+	return minThroughput, found
 }
 
 func (s *schedulingThroughputMeasurement) stop() {
@@ -168,3 +245,19 @@ type schedulingThroughput struct {
 	Perc90  float64 `json:"perc90"`
 	Perc99  float64 `json:"perc99"`
 }
+
+type schedulingThroughputSample struct {
+	Time       time.Time `json:"time"`
+	Scheduled  int       `json:"scheduled"`
+	Throughput float64   `json:"throughput"`
+	// Waiting is the number of pods waiting to be scheduled.
+	Waiting int `json:"waiting"`
+}
+
+type schedulingThroughputSeries struct {
+	Interval string                       `json:"interval"`
+	Max      float64                      `json:"max"`
+	Samples  []schedulingThroughputSample `json:"samples"`
+	// MinBusy is the minimal throughput of the busy intervals.
+	MinBusy float64 `json:"minBusy"`
+}
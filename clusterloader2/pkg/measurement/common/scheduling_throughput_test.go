@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+)
+
+func newThroughputSamples(waiting []int, throughputs []float64) []schedulingThroughputSample {
+	var samples []schedulingThroughputSample
+	for i := range waiting {
+		samples = append(samples, schedulingThroughputSample{Waiting: waiting[i], Throughput: throughputs[i]})
+	}
+	return samples
+}
+
+func TestMinBusyThroughput(t *testing.T) {
+	cases := []struct {
+		name        string
+		waiting     []int
+		throughputs []float64
+		want        float64
+		wantFound   bool
+	}{
+		{name: "no samples"},
+		{name: "no waiting pods", waiting: []int{0, 0, 0}, throughputs: []float64{0, 50, 0}},
+		{name: "single busy sample", waiting: []int{100}, throughputs: []float64{20}},
+		{
+			name:        "idle intervals are skipped",
+			waiting:     []int{0, 500, 300, 100, 0, 0},
+			throughputs: []float64{0, 10, 40, 40, 20, 0},
+			want:        40,
+			wantFound:   true,
+		},
+		{
+			name:        "slow busy interval",
+			waiting:     []int{500, 400, 380, 100, 0},
+			throughputs: []float64{0, 20, 4, 56, 20},
+			want:        4,
+			wantFound:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, found := minBusyThroughput(newThroughputSamples(c.waiting, c.throughputs))
+			assert.Equal(t, c.wantFound, found)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestSchedulingThroughputThreshold(t *testing.T) {
+	cases := []struct {
+		name          string
+		threshold     float64
+		waiting       []int
+		throughputs   []float64
+		wantViolation bool
+	}{
+		{name: "no threshold", waiting: []int{500, 400, 0}, throughputs: []float64{0, 1, 80}},
+		{name: "above threshold", threshold: 10, waiting: []int{500, 400, 200, 0}, throughputs: []float64{0, 20, 40, 40}},
+		// Low throughput at the beginning and at the end doesn't fail the measurement.
+		{name: "idle intervals", threshold: 10, waiting: []int{0, 400, 200, 0, 0}, throughputs: []float64{0, 5, 40, 40, 0}},
+		// High maximal throughput doesn't hide the slow interval.
+		{name: "below threshold", threshold: 10, waiting: []int{500, 490, 200, 0}, throughputs: []float64{0, 2, 58, 40}, wantViolation: true},
+		{name: "no busy intervals", threshold: 10, waiting: []int{0, 0}, throughputs: []float64{0, 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &schedulingThroughputMeasurement{
+				samples:   newThroughputSamples(c.waiting, c.throughputs),
+				threshold: c.threshold,
+				isRunning: true,
+				stopCh:    make(chan struct{}),
+			}
+			summaries, err := s.gather()
+			assert.Len(t, summaries, 2)
+			if c.wantViolation {
+				assert.True(t, errors.IsMetricViolationError(err), "unexpected error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slos
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+// apiCallMatcher matches api calls by resource, subresource, verb and scope.
+// Every field is a regular expression, which has to match the whole value.
+// Empty field matches any value.
+type apiCallMatcher struct {
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource"`
+	Verb        string `json:"verb"`
+	Scope       string `json:"scope"`
+
+	regexps []*regexp.Regexp
+}
+
+func (m *apiCallMatcher) compile() error {
+	m.regexps = nil
+	for _, pattern := range []string{m.Resource, m.Subresource, m.Verb, m.Scope} {
+		if pattern == "" {
+			m.regexps = append(m.regexps, nil)
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid api call pattern %q: %v", pattern, err)
+		}
+		m.regexps = append(m.regexps, re)
+	}
+	return nil
+}
+
+func (m *apiCallMatcher) matches(resource, subresource, verb, scope string) bool {
+	for i, value := range []string{resource, subresource, verb, scope} {
+		if m.regexps[i] != nil && !m.regexps[i].MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// apiCallThreshold specifies perc99 latency threshold of the matching api calls.
+type apiCallThreshold struct {
+	apiCallMatcher
+	Threshold string `json:"threshold"`
+
+	threshold time.Duration
+}
+
+// apiCallSLOConfig specifies latency thresholds and exclusions of api calls.
+// The first matching threshold is used.
+type apiCallSLOConfig struct {
+	Thresholds []apiCallThreshold `json:"thresholds"`
+	Exclusions []apiCallMatcher   `json:"exclusions"`
+	// ReplaceDefaultExclusions makes the specified exclusions replace the default ones
+	// instead of being added to them.
+	ReplaceDefaultExclusions bool `json:"replaceDefaultExclusions"`
+}
+
+// defaultAPICallThresholds returns thresholds defined by the api call latency SLO.
+// For big clusters higher thresholds are used for list calls.
+func defaultAPICallThresholds(isBigCluster bool) []apiCallThreshold {
+	thresholds := []apiCallThreshold{}
+	if isBigCluster {
+		thresholds = append(thresholds,
+			apiCallThreshold{apiCallMatcher: apiCallMatcher{Verb: "LIST", Scope: "cluster"}, threshold: apiClusterScopeListCallThreshold},
+			apiCallThreshold{apiCallMatcher: apiCallMatcher{Verb: "LIST"}, threshold: apiListCallLatencyThreshold},
+		)
+	}
+	return append(thresholds, apiCallThreshold{threshold: apiCallLatencyThreshold})
+}
+
+// defaultAPICallExclusions returns api calls, which are not covered by the api call latency SLO.
+func defaultAPICallExclusions() []apiCallMatcher {
+	return []apiCallMatcher{
+		{Resource: "events"},
+		// TODO: figure out why we're getting non-capitalized proxy and fix this.
+		{Verb: "WATCH|WATCHLIST|PROXY|proxy|CONNECT"},
+	}
+}
+
+// loadAPICallSLOConfig creates api call SLO config based on measurement params.
+// Thresholds and exclusions can be specified with thresholds and exclusions params
+// and in the file given by sloConfig param. Thresholds from params take precedence
+// over the ones from the file, default thresholds are used if none of them matches.
+// Specified exclusions are added to the default ones, unless replaceDefaultExclusions is set.
+func loadAPICallSLOConfig(config *measurement.MeasurementConfig, isBigCluster bool) (*apiCallSLOConfig, error) {
+	result := &apiCallSLOConfig{}
+	if config != nil {
+		thresholds, err := util.GetMapArrayOrDefault(config.Params, "thresholds", nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = convertParam(thresholds, &result.Thresholds); err != nil {
+			return nil, fmt.Errorf("thresholds param parsing error: %v", err)
+		}
+		exclusions, err := util.GetMapArrayOrDefault(config.Params, "exclusions", nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = convertParam(exclusions, &result.Exclusions); err != nil {
+			return nil, fmt.Errorf("exclusions param parsing error: %v", err)
+		}
+		if result.ReplaceDefaultExclusions, err = util.GetBoolOrDefault(config.Params, "replaceDefaultExclusions", false); err != nil {
+			return nil, err
+		}
+
+		sloConfigPath, err := util.GetStringOrDefault(config.Params, "sloConfig", "")
+		if err != nil {
+			return nil, err
+		}
+		if sloConfigPath != "" {
+			fileConfig := apiCallSLOConfig{}
+			mapping := map[string]interface{}{"Nodes": config.ClusterFramework.GetClusterConfig().Nodes}
+			if err = config.TemplateProvider.TemplateInto(sloConfigPath, mapping, &fileConfig); err != nil {
+				return nil, fmt.Errorf("slo config reading error: %v", err)
+			}
+			result.Thresholds = append(result.Thresholds, fileConfig.Thresholds...)
+			result.Exclusions = append(result.Exclusions, fileConfig.Exclusions...)
+			result.ReplaceDefaultExclusions = result.ReplaceDefaultExclusions || fileConfig.ReplaceDefaultExclusions
+		}
+	}
+
+	for i := range result.Thresholds {
+		threshold := &result.Thresholds[i]
+		var err error
+		if threshold.threshold, err = time.ParseDuration(threshold.Threshold); err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %v", threshold.Threshold, err)
+		}
+	}
+	result.Thresholds = append(result.Thresholds, defaultAPICallThresholds(isBigCluster)...)
+	if !result.ReplaceDefaultExclusions {
+		result.Exclusions = append(result.Exclusions, defaultAPICallExclusions()...)
+	}
+	return result, result.compile()
+}
+
+func convertParam(param []map[string]interface{}, obj interface{}) error {
+	if param == nil {
+		return nil
+	}
+	b, err := json.Marshal(param)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, obj)
+}
+
+func (c *apiCallSLOConfig) compile() error {
+	for i := range c.Thresholds {
+		if err := c.Thresholds[i].compile(); err != nil {
+			return err
+		}
+	}
+	for i := range c.Exclusions {
+		if err := c.Exclusions[i].compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isExcluded checks whether the api call is excluded from the SLO.
+func (c *apiCallSLOConfig) isExcluded(resource, subresource, verb, scope string) bool {
+	for i := range c.Exclusions {
+		if c.Exclusions[i].matches(resource, subresource, verb, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// getThreshold returns perc99 latency threshold of the api call.
+func (c *apiCallSLOConfig) getThreshold(resource, subresource, verb, scope string) time.Duration {
+	for i := range c.Thresholds {
+		if c.Thresholds[i].matches(resource, subresource, verb, scope) {
+			return c.Thresholds[i].threshold
+		}
+	}
+	return apiCallLatencyThreshold
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+)
+
+func TestDefaultAPICallSLOConfig(t *testing.T) {
+	sloConfig, err := loadAPICallSLOConfig(nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, apiCallLatencyThreshold, sloConfig.getThreshold("pods", "", "POST", "namespace"))
+	assert.Equal(t, apiListCallLatencyThreshold, sloConfig.getThreshold("pods", "", "LIST", "namespace"))
+	assert.Equal(t, apiClusterScopeListCallThreshold, sloConfig.getThreshold("pods", "", "LIST", "cluster"))
+	assert.True(t, sloConfig.isExcluded("events", "", "POST", "namespace"))
+	assert.True(t, sloConfig.isExcluded("pods", "", "WATCH", "cluster"))
+	assert.False(t, sloConfig.isExcluded("pods", "", "GET", "namespace"))
+
+	sloConfig, err = loadAPICallSLOConfig(nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, apiCallLatencyThreshold, sloConfig.getThreshold("pods", "", "LIST", "cluster"))
+}
+
+func TestAPICallSLOConfigFromParams(t *testing.T) {
+	config := &measurement.MeasurementConfig{
+		Params: map[string]interface{}{
+			"thresholds": []interface{}{
+				map[string]interface{}{"resource": "foos", "threshold": "200ms"},
+				map[string]interface{}{"resource": "pods", "subresource": "log", "threshold": "30s"},
+			},
+			"exclusions": []interface{}{
+				map[string]interface{}{"verb": "WATCH|CONNECT"},
+			},
+		},
+	}
+	sloConfig, err := loadAPICallSLOConfig(config, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, 200*time.Millisecond, sloConfig.getThreshold("foos", "", "LIST", "cluster"))
+	assert.Equal(t, 30*time.Second, sloConfig.getThreshold("pods", "log", "GET", "namespace"))
+	assert.Equal(t, apiCallLatencyThreshold, sloConfig.getThreshold("pods", "", "GET", "namespace"))
+	assert.Equal(t, apiClusterScopeListCallThreshold, sloConfig.getThreshold("pods", "", "LIST", "cluster"))
+	// Default exclusions are kept.
+	assert.True(t, sloConfig.isExcluded("events", "", "POST", "namespace"))
+	assert.True(t, sloConfig.isExcluded("pods", "", "WATCH", "cluster"))
+}
+
+func TestAPICallSLOConfigCustomExclusion(t *testing.T) {
+	config := &measurement.MeasurementConfig{
+		Params: map[string]interface{}{
+			"exclusions": []interface{}{
+				map[string]interface{}{"resource": "foos", "verb": "LIST"},
+			},
+		},
+	}
+	sloConfig, err := loadAPICallSLOConfig(config, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.True(t, sloConfig.isExcluded("foos", "", "LIST", "cluster"))
+	assert.False(t, sloConfig.isExcluded("foos", "", "GET", "namespace"))
+	// Patterns have to match the whole value.
+	assert.False(t, sloConfig.isExcluded("foosbars", "", "LIST", "cluster"))
+	// Default exclusions still apply.
+	assert.True(t, sloConfig.isExcluded("pods", "", "WATCH", "cluster"))
+	assert.True(t, sloConfig.isExcluded("events", "", "POST", "namespace"))
+}
+
+func TestAPICallSLOConfigReplaceDefaultExclusions(t *testing.T) {
+	config := &measurement.MeasurementConfig{
+		Params: map[string]interface{}{
+			"exclusions": []interface{}{
+				map[string]interface{}{"verb": "WATCH"},
+			},
+			"replaceDefaultExclusions": true,
+		},
+	}
+	sloConfig, err := loadAPICallSLOConfig(config, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.True(t, sloConfig.isExcluded("pods", "", "WATCH", "cluster"))
+	assert.False(t, sloConfig.isExcluded("pods", "", "WATCHLIST", "cluster"))
+	assert.False(t, sloConfig.isExcluded("events", "", "POST", "namespace"))
+}
+
+func TestAPICallSLOConfigInvalidParams(t *testing.T) {
+	for _, params := range []map[string]interface{}{
+		{"thresholds": []interface{}{map[string]interface{}{"resource": "pods", "threshold": "fast"}}},
+		{"exclusions": []interface{}{map[string]interface{}{"verb": "("}}},
+	} {
+		_, err := loadAPICallSLOConfig(&measurement.MeasurementConfig{Params: params}, true)
+		assert.Error(t, err)
+	}
+}
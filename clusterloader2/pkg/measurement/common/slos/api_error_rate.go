@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"k8s.io/klog"
+
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	apiErrorRateMeasurementName = "APIErrorRate"
+
+	errorRateFilters = `verb!~"WATCH|WATCHLIST|PROXY|proxy|CONNECT"`
+
+	// errorRateQuery: %v should be replaced with (1) filters and (2) query window size.
+	// apiserver_request_count is used by older api servers.
+	errorRateQuery = "sum(increase(apiserver_request_total{%[1]v}[%[2]v])) by (resource, verb, code) or " +
+		"sum(increase(apiserver_request_count{%[1]v}[%[2]v])) by (resource, verb, code)"
+
+	defaultServerErrorRatioThreshold = 0.01
+	defaultThrottlingRatioThreshold  = 0.05
+
+	throttlingCode = "429"
+)
+
+func init() {
+	create := func() measurement.Measurement { return createPrometheusMeasurement(&apiErrorRateGatherer{}) }
+	if err := measurement.Register(apiErrorRateMeasurementName, create); err != nil {
+		klog.Fatalf("Cannot register %s: %v", apiErrorRateMeasurementName, err)
+	}
+}
+
+// apiCallErrors represents number of all, server error (5xx) and throttled (429) calls.
+type apiCallErrors struct {
+	resource     string
+	verb         string
+	count        float64
+	serverErrors float64
+	throttled    float64
+}
+
+func (a *apiCallErrors) serverErrorRatio() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.serverErrors / a.count
+}
+
+func (a *apiCallErrors) throttlingRatio() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.throttled / a.count
+}
+
+func (a *apiCallErrors) add(code string, count float64) {
+	a.count += count
+	if strings.HasPrefix(code, "5") {
+		a.serverErrors += count
+	}
+	if code == throttlingCode {
+		a.throttled += count
+	}
+}
+
+func (a *apiCallErrors) toPerfData() measurementutil.DataItem {
+	return measurementutil.DataItem{
+		Data: map[string]float64{
+			"ServerErrorRatio": a.serverErrorRatio(),
+			"ThrottlingRatio":  a.throttlingRatio(),
+			"Count":            a.count,
+		},
+		Unit: "ratio",
+		Labels: map[string]string{
+			"Resource": a.resource,
+			"Verb":     a.verb,
+		},
+	}
+}
+
+// apiErrorRateGatherer computes ratios of server errors (5xx) and throttled requests (429)
+// among api calls made during the measurement.
+type apiErrorRateGatherer struct {
+	serverErrorRatioThreshold float64
+	throttlingRatioThreshold  float64
+}
+
+// Configure sets thresholds from the measurement params.
+func (a *apiErrorRateGatherer) Configure(config *measurement.MeasurementConfig) error {
+	var err error
+	if a.serverErrorRatioThreshold, err = util.GetFloat64OrDefault(config.Params, "serverErrorRatioThreshold", defaultServerErrorRatioThreshold); err != nil {
+		return err
+	}
+	a.throttlingRatioThreshold, err = util.GetFloat64OrDefault(config.Params, "throttlingRatioThreshold", defaultThrottlingRatioThreshold)
+	return err
+}
+
+func (a *apiErrorRateGatherer) Gather(executor QueryExecutor, startTime time.Time) (measurement.Summary, error) {
+	measurementEnd := time.Now()
+	query := fmt.Sprintf(errorRateQuery, errorRateFilters, measurementutil.ToPrometheusTime(measurementEnd.Sub(startTime)))
+	samples, err := executor.Query(query, measurementEnd)
+	if err != nil {
+		klog.Errorf("%s: samples gathering error: %v", a, err)
+		return nil, err
+	}
+	total, calls := a.convertToApiCallErrors(samples)
+
+	perfData := &measurementutil.PerfData{Version: metricVersion}
+	perfData.DataItems = append(perfData.DataItems, total.toPerfData())
+	for _, call := range calls {
+		perfData.DataItems = append(perfData.DataItems, call.toPerfData())
+	}
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(apiErrorRateMeasurementName, "json", content)
+
+	klog.Infof("%s: %v calls, server error ratio: %v, throttling ratio: %v", a, total.count, total.serverErrorRatio(), total.throttlingRatio())
+	var violations []string
+	if ratio := total.serverErrorRatio(); ratio > a.serverErrorRatioThreshold {
+		violations = append(violations, fmt.Sprintf("server error ratio %v, expected <= %v", ratio, a.serverErrorRatioThreshold))
+	}
+	if ratio := total.throttlingRatio(); ratio > a.throttlingRatioThreshold {
+		violations = append(violations, fmt.Sprintf("throttling ratio %v, expected <= %v", ratio, a.throttlingRatioThreshold))
+	}
+	if len(violations) > 0 {
+		return summary, errors.NewMetricViolationError("api error rate", strings.Join(violations, "; "))
+	}
+	return summary, nil
+}
+
+func (a *apiErrorRateGatherer) String() string {
+	return apiErrorRateMeasurementName
+}
+
+// convertToApiCallErrors aggregates samples by resource and verb.
+// Returned calls are sorted by server error ratio (and resource and verb for equal ratios).
+func (a *apiErrorRateGatherer) convertToApiCallErrors(samples []*model.Sample) (*apiCallErrors, []*apiCallErrors) {
+	total := &apiCallErrors{resource: "all", verb: "all"}
+	callsMap := make(map[string]*apiCallErrors)
+	for _, sample := range samples {
+		resource := string(sample.Metric["resource"])
+		verb := string(sample.Metric["verb"])
+		code := string(sample.Metric["code"])
+		count := float64(sample.Value)
+
+		key := resource + "|" + verb
+		if _, ok := callsMap[key]; !ok {
+			callsMap[key] = &apiCallErrors{resource: resource, verb: verb}
+		}
+		callsMap[key].add(code, count)
+		total.add(code, count)
+	}
+
+	calls := make([]*apiCallErrors, 0, len(callsMap))
+	for _, call := range callsMap {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].serverErrorRatio() != calls[j].serverErrorRatio() {
+			return calls[i].serverErrorRatio() > calls[j].serverErrorRatio()
+		}
+		if calls[i].resource != calls[j].resource {
+			return calls[i].resource < calls[j].resource
+		}
+		return calls[i].verb < calls[j].verb
+	})
+	return total, calls
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slos
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+func createErrorRateSample(resource, verb, code string, count float64) *model.Sample {
+	return &model.Sample{
+		Value: model.SampleValue(count),
+		Metric: model.Metric{
+			"resource": model.LabelValue(resource),
+			"verb":     model.LabelValue(verb),
+			"code":     model.LabelValue(code),
+		},
+	}
+}
+
+func TestAPIErrorRateGather(t *testing.T) {
+	samples := []*model.Sample{
+		createErrorRateSample("pods", "POST", "201", 90),
+		createErrorRateSample("pods", "POST", "500", 5),
+		createErrorRateSample("pods", "POST", "429", 5),
+		createErrorRateSample("nodes", "GET", "200", 100),
+	}
+	cases := []struct {
+		name          string
+		params        map[string]interface{}
+		wantViolation bool
+	}{
+		{name: "default thresholds", params: map[string]interface{}{}, wantViolation: true},
+		{name: "loose thresholds", params: map[string]interface{}{"serverErrorRatioThreshold": 0.05, "throttlingRatioThreshold": 0.05}},
+		{name: "tight throttling threshold", params: map[string]interface{}{"serverErrorRatioThreshold": 0.05, "throttlingRatioThreshold": 0.01}, wantViolation: true},
+	}
+	for _, c := range cases {
+		g := &apiErrorRateGatherer{}
+		if err := g.Configure(&measurement.MeasurementConfig{Params: c.params}); err != nil {
+			t.Fatalf("%s: configuring gatherer error: %v", c.name, err)
+		}
+		summary, err := g.Gather(&fakeExecutor{samples: samples}, time.Now())
+		if c.wantViolation {
+			assert.True(t, errors.IsMetricViolationError(err), "%s: expected violation, got %v", c.name, err)
+		} else {
+			assert.NoError(t, err, c.name)
+		}
+		if !assert.NotNil(t, summary, c.name) {
+			continue
+		}
+		assert.Equal(t, apiErrorRateMeasurementName, summary.SummaryName())
+
+		var data measurementutil.PerfData
+		if err := json.Unmarshal([]byte(summary.SummaryContent()), &data); err != nil {
+			t.Fatalf("%s: error while decoding summary: %v", c.name, err)
+		}
+		assert.Equal(t, []measurementutil.DataItem{
+			{
+				Data:   map[string]float64{"ServerErrorRatio": 0.025, "ThrottlingRatio": 0.025, "Count": 200},
+				Unit:   "ratio",
+				Labels: map[string]string{"Resource": "all", "Verb": "all"},
+			},
+			{
+				Data:   map[string]float64{"ServerErrorRatio": 0.05, "ThrottlingRatio": 0.05, "Count": 100},
+				Unit:   "ratio",
+				Labels: map[string]string{"Resource": "pods", "Verb": "POST"},
+			},
+			{
+				Data:   map[string]float64{"ServerErrorRatio": 0, "ThrottlingRatio": 0, "Count": 100},
+				Unit:   "ratio",
+				Labels: map[string]string{"Resource": "nodes", "Verb": "GET"},
+			},
+		}, data.DataItems, c.name)
+	}
+}
@@ -23,7 +23,6 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
-	"k8s.io/apimachinery/pkg/util/sets"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
@@ -84,7 +83,11 @@ func (a *apiResponsivenessMeasurement) Execute(config *measurement.MeasurementCo
 		if err != nil {
 			return nil, err
 		}
-		summary, err := a.apiserverMetricsGather(config.ClusterFramework.GetClientSets().GetClient(), nodeCount)
+		sloConfig, err := loadAPICallSLOConfig(config, nodeCount > bigClusterNodeCountThreshold)
+		if err != nil {
+			return nil, err
+		}
+		summary, err := a.apiserverMetricsGather(config.ClusterFramework.GetClientSets().GetClient(), sloConfig)
 		if err != nil && !errors.IsMetricViolationError(err) {
 			return nil, err
 		}
@@ -102,9 +105,8 @@ func (*apiResponsivenessMeasurement) String() string {
 	return apiResponsivenessMeasurementName
 }
 
-func (a *apiResponsivenessMeasurement) apiserverMetricsGather(c clientset.Interface, nodeCount int) (measurement.Summary, error) {
-	isBigCluster := (nodeCount > bigClusterNodeCountThreshold)
-	metrics, err := readLatencyMetrics(c)
+func (a *apiResponsivenessMeasurement) apiserverMetricsGather(c clientset.Interface, sloConfig *apiCallSLOConfig) (measurement.Summary, error) {
+	metrics, err := readLatencyMetrics(c, sloConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -112,17 +114,10 @@ func (a *apiResponsivenessMeasurement) apiserverMetricsGather(c clientset.Interf
 	var badMetrics []string
 	top := 5
 	for i := range metrics.ApiCalls {
-		latency := metrics.ApiCalls[i].Latency.Perc99
-		isListCall := (metrics.ApiCalls[i].Verb == "LIST")
-		isClusterScopedCall := (metrics.ApiCalls[i].Scope == "cluster")
+		call := &metrics.ApiCalls[i]
+		latency := call.Latency.Perc99
 		isBad := false
-		latencyThreshold := apiCallLatencyThreshold
-		if isListCall && isBigCluster {
-			latencyThreshold = apiListCallLatencyThreshold
-			if isClusterScopedCall {
-				latencyThreshold = apiClusterScopeListCallThreshold
-			}
-		}
+		latencyThreshold := sloConfig.getThreshold(call.Resource, call.Subresource, call.Verb, call.Scope)
 		if latency > latencyThreshold {
 			isBad = true
 			badMetrics = append(badMetrics, fmt.Sprintf("got: %+v; expected perc99 <= %v", metrics.ApiCalls[i], latencyThreshold))
@@ -159,7 +154,7 @@ func apiserverMetricsReset(c clientset.Interface) error {
 	return nil
 }
 
-func readLatencyMetrics(c clientset.Interface) (*apiResponsiveness, error) {
+func readLatencyMetrics(c clientset.Interface, sloConfig *apiCallSLOConfig) (*apiResponsiveness, error) {
 	var a apiResponsiveness
 
 	body, err := getMetrics(c)
@@ -172,10 +167,6 @@ func readLatencyMetrics(c clientset.Interface) (*apiResponsiveness, error) {
 		return nil, err
 	}
 
-	ignoredResources := sets.NewString("events")
-	// TODO: figure out why we're getting non-capitalized proxy and fix this.
-	ignoredVerbs := sets.NewString("WATCH", "WATCHLIST", "PROXY", "proxy", "CONNECT")
-
 	for _, sample := range samples {
 		// Example line:
 		// apiserver_request_latencies_summary{resource="namespaces",verb="LIST",quantile="0.99"} 908
@@ -189,7 +180,7 @@ func readLatencyMetrics(c clientset.Interface) (*apiResponsiveness, error) {
 		subresource := string(sample.Metric["subresource"])
 		verb := string(sample.Metric["verb"])
 		scope := string(sample.Metric["scope"])
-		if ignoredResources.Has(resource) || ignoredVerbs.Has(verb) {
+		if sloConfig.isExcluded(resource, subresource, verb, scope) {
 			continue
 		}
 
@@ -39,14 +39,12 @@ import (
 const (
 	apiResponsivenessPrometheusMeasurementName = "APIResponsivenessPrometheus"
 
-	filters = `resource!="events", verb!~"WATCH|WATCHLIST|PROXY|proxy|CONNECT"`
+	// latencyQuery: %v should be replaced with query window size.
+	// Api calls excluded from the SLO are filtered out after querying.
+	latencyQuery = "quantile_over_time(0.99, apiserver:apiserver_request_latency:histogram_quantile[%v])"
 
-	// latencyQuery: %v should be replaced with (1) filters and (2) query window size.
-	// TODO(krzysied): figure out why we're getting non-capitalized proxy and fix this.
-	latencyQuery = "quantile_over_time(0.99, apiserver:apiserver_request_latency:histogram_quantile{%v}[%v])"
-
-	// countQuery %v should be replaced with (1) filters and (2) query window size.
-	countQuery = "sum(increase(apiserver_request_duration_seconds_count{%v}[%v])) by (resource, subresource, scope, verb)"
+	// countQuery %v should be replaced with query window size.
+	countQuery = "sum(increase(apiserver_request_duration_seconds_count[%v])) by (resource, subresource, scope, verb)"
 
 	latencyWindowSize = 5 * time.Minute
 
@@ -61,10 +59,22 @@ func init() {
 	}
 }
 
-type apiResponsivenessGatherer struct{}
+type apiResponsivenessGatherer struct {
+	sloConfig *apiCallSLOConfig
+}
+
+// Configure loads SLO thresholds and exclusions from the measurement params.
+func (a *apiResponsivenessGatherer) Configure(config *measurement.MeasurementConfig) error {
+	sloConfig, err := loadAPICallSLOConfig(config, true)
+	if err != nil {
+		return err
+	}
+	a.sloConfig = sloConfig
+	return nil
+}
 
 func (a *apiResponsivenessGatherer) Gather(executor QueryExecutor, startTime time.Time) (measurement.Summary, error) {
-	apiCalls, err := a.gatherApiCalls(executor, startTime)
+	apiCalls, err := a.gatherApiCalls(executor, startTime, a.sloConfig)
 	if err != nil {
 		klog.Errorf("%s: samples gathering error: %v", apiResponsivenessMeasurementName, err)
 		return nil, err
@@ -76,7 +86,7 @@ func (a *apiResponsivenessGatherer) Gather(executor QueryExecutor, startTime tim
 	top := topToPrint
 	for _, apiCall := range metrics.ApiCalls {
 		isBad := false
-		sloThreshold := getSLOThreshold(apiCall.Verb, apiCall.Scope)
+		sloThreshold := a.sloConfig.getThreshold(apiCall.Resource, apiCall.Subresource, apiCall.Verb, apiCall.Scope)
 		if apiCall.Latency.Perc99 > sloThreshold {
 			isBad = true
 			badMetrics = append(badMetrics, fmt.Sprintf("got: %+v; expected perc99 <= %v", apiCall, sloThreshold))
@@ -106,7 +116,7 @@ func (a *apiResponsivenessGatherer) String() string {
 	return apiResponsivenessPrometheusMeasurementName
 }
 
-func (a *apiResponsivenessGatherer) gatherApiCalls(executor QueryExecutor, startTime time.Time) ([]apiCall, error) {
+func (a *apiResponsivenessGatherer) gatherApiCalls(executor QueryExecutor, startTime time.Time, sloConfig *apiCallSLOConfig) ([]apiCall, error) {
 	measurementEnd := time.Now()
 	measurementDuration := measurementEnd.Sub(startTime)
 	// Latency measurement is based on 5m window aggregation,
@@ -115,20 +125,20 @@ func (a *apiResponsivenessGatherer) gatherApiCalls(executor QueryExecutor, start
 	if latencyMeasurementDuration < time.Minute {
 		latencyMeasurementDuration = time.Minute
 	}
-	timeBoundedLatencyQuery := fmt.Sprintf(latencyQuery, filters, measurementutil.ToPrometheusTime(latencyMeasurementDuration))
+	timeBoundedLatencyQuery := fmt.Sprintf(latencyQuery, measurementutil.ToPrometheusTime(latencyMeasurementDuration))
 	latencySamples, err := executor.Query(timeBoundedLatencyQuery, measurementEnd)
 	if err != nil {
 		return nil, err
 	}
-	timeBoundedCountQuery := fmt.Sprintf(countQuery, filters, measurementutil.ToPrometheusTime(measurementDuration))
+	timeBoundedCountQuery := fmt.Sprintf(countQuery, measurementutil.ToPrometheusTime(measurementDuration))
 	countSamples, err := executor.Query(timeBoundedCountQuery, measurementEnd)
 	if err != nil {
 		return nil, err
 	}
-	return a.convertToApiCalls(latencySamples, countSamples)
+	return a.convertToApiCalls(latencySamples, countSamples, sloConfig)
 }
 
-func (a *apiResponsivenessGatherer) convertToApiCalls(latencySamples, countSamples []*model.Sample) ([]apiCall, error) {
+func (a *apiResponsivenessGatherer) convertToApiCalls(latencySamples, countSamples []*model.Sample, sloConfig *apiCallSLOConfig) ([]apiCall, error) {
 	apiCalls := make(map[string]*apiCall)
 
 	for _, sample := range latencySamples {
@@ -136,6 +146,9 @@ func (a *apiResponsivenessGatherer) convertToApiCalls(latencySamples, countSampl
 		subresource := string(sample.Metric["subresource"])
 		verb := string(sample.Metric["verb"])
 		scope := string(sample.Metric["scope"])
+		if sloConfig.isExcluded(resource, subresource, verb, scope) {
+			continue
+		}
 		quantile, err := strconv.ParseFloat(string(sample.Metric["quantile"]), 64)
 		if err != nil {
 			return nil, err
@@ -150,6 +163,9 @@ func (a *apiResponsivenessGatherer) convertToApiCalls(latencySamples, countSampl
 		subresource := string(sample.Metric["subresource"])
 		verb := string(sample.Metric["verb"])
 		scope := string(sample.Metric["scope"])
+		if sloConfig.isExcluded(resource, subresource, verb, scope) {
+			continue
+		}
 
 		count := int(math.Round(float64(sample.Value)))
 		addCount(apiCalls, resource, subresource, verb, scope, count)
@@ -193,13 +209,3 @@ func addCount(apiCalls map[string]*apiCall, resource, subresource, verb, scope s
 func getMetricKey(resource, subresource, verb, scope string) string {
 	return fmt.Sprintf("%s|%s|%s|%s", resource, subresource, verb, scope)
 }
-
-func getSLOThreshold(verb, scope string) time.Duration {
-	if verb != "LIST" {
-		return apiCallLatencyThreshold
-	}
-	if scope == "cluster" {
-		return apiClusterScopeListCallThreshold
-	}
-	return apiListCallLatencyThreshold
-}
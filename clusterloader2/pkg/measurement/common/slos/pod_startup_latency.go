@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/chaos"
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
 	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
@@ -70,11 +71,15 @@ type podStartupLatencyMeasurement struct {
 	nodeNames       map[string]string
 	threshold       time.Duration
 	selectorsString string
+	// chaosEvents is set if samples overlapping with chaos events should be annotated.
+	chaosEvents *chaos.EventRecorder
 }
 
 // Execute supports two actions:
 // - start - Starts to observe pods and pods events.
 // - gather - Gathers and prints current pod latency data.
+// If annotateChaosEvents param is set, pods which startup overlapped with
+// simulated failures are annotated and summarized separately.
 // Does NOT support concurrency. Multiple calls to this measurement
 // shouldn't be done within one step.
 func (p *podStartupLatencyMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
@@ -101,6 +106,14 @@ func (p *podStartupLatencyMeasurement) Execute(config *measurement.MeasurementCo
 		if err != nil {
 			return nil, err
 		}
+		annotateChaosEvents, err := util.GetBoolOrDefault(config.Params, "annotateChaosEvents", false)
+		if err != nil {
+			return nil, err
+		}
+		p.chaosEvents = nil
+		if annotateChaosEvents {
+			p.chaosEvents = config.ChaosEvents
+		}
 		return nil, p.start(config.ClusterFramework.GetClientSets().GetClient())
 	case "gather":
 		return p.gather(config.ClusterFramework.GetClientSets().GetClient(), config.Identifier)
@@ -161,6 +174,11 @@ func (p *podStartupLatencyMeasurement) gather(c clientset.Interface, identifier
 
 	p.stop()
 
+	var chaosEvents []chaos.Event
+	if p.chaosEvents != nil {
+		chaosEvents = p.chaosEvents.Events()
+	}
+
 	if err := p.gatherScheduleTimes(c); err != nil {
 		return nil, err
 	}
@@ -193,6 +211,10 @@ func (p *podStartupLatencyMeasurement) gather(c clientset.Interface, identifier
 		watchLag = append(watchLag, podLatencyData{Name: key, Node: node, Latency: watch.Time.Sub(run.Time)})
 		e2eLag = append(e2eLag, podLatencyData{Name: key, Node: node, Latency: watch.Time.Sub(create.Time)})
 	}
+	var e2eChaosLag, e2eBaselineLag []measurementutil.LatencyData
+	if p.chaosEvents != nil {
+		e2eChaosLag, e2eBaselineLag = splitByChaosEvents(e2eLag, p.createTimes, chaosEvents)
+	}
 
 	sort.Sort(measurementutil.LatencySlice(scheduleLag))
 	sort.Sort(measurementutil.LatencySlice(startupLag))
@@ -205,6 +227,12 @@ func (p *podStartupLatencyMeasurement) gather(c clientset.Interface, identifier
 	p.printLatencies(watchLag, "worst run-to-watch latencies")
 	p.printLatencies(schedToWatchLag, "worst schedule-to-watch latencies")
 	p.printLatencies(e2eLag, "worst e2e latencies")
+	if p.chaosEvents != nil {
+		sort.Sort(measurementutil.LatencySlice(e2eChaosLag))
+		sort.Sort(measurementutil.LatencySlice(e2eBaselineLag))
+		p.printLatencies(e2eChaosLag, "worst e2e latencies during chaos events")
+		p.printLatencies(e2eBaselineLag, "worst e2e latencies outside of chaos events")
+	}
 
 	podStartupLatency := &podStartupLatency{
 		CreateToScheduleLatency: measurementutil.NewLatencyMetric(scheduleLag),
@@ -213,6 +241,12 @@ func (p *podStartupLatencyMeasurement) gather(c clientset.Interface, identifier
 		ScheduleToWatchLatency:  measurementutil.NewLatencyMetric(schedToWatchLag),
 		E2ELatency:              measurementutil.NewLatencyMetric(e2eLag),
 	}
+	if p.chaosEvents != nil {
+		e2eChaosLatency := measurementutil.NewLatencyMetric(e2eChaosLag)
+		e2eBaselineLatency := measurementutil.NewLatencyMetric(e2eBaselineLag)
+		podStartupLatency.E2EChaosLatency = &e2eChaosLatency
+		podStartupLatency.E2EBaselineLatency = &e2eBaselineLatency
+	}
 
 	var err error
 	if successRatio := float32(len(e2eLag)) / float32(len(p.createTimes)); successRatio < successfulStartupRatioThreshold {
@@ -295,6 +329,29 @@ func (p *podStartupLatencyMeasurement) checkPod(_, obj interface{}) {
 	}
 }
 
+// splitByChaosEvents annotates e2e latencies with types of the chaos events overlapping with the pod startups
+// and splits them into the ones overlapping with any event and the baseline ones.
+// Startup of the pod lasts for its e2e latency since its creation.
+func splitByChaosEvents(e2eLag []measurementutil.LatencyData, createTimes map[string]metav1.Time, events []chaos.Event) (chaosLag, baselineLag []measurementutil.LatencyData) {
+	for i := range e2eLag {
+		e2e := e2eLag[i].(podLatencyData)
+		start := createTimes[e2e.Name].Time
+		end := start.Add(e2e.Latency)
+		for j := range events {
+			if events[j].Overlaps(start, end) {
+				e2e.ChaosEvents = append(e2e.ChaosEvents, events[j].Type)
+			}
+		}
+		e2eLag[i] = e2e
+		if len(e2e.ChaosEvents) > 0 {
+			chaosLag = append(chaosLag, e2e)
+		} else {
+			baselineLag = append(baselineLag, e2e)
+		}
+	}
+	return chaosLag, baselineLag
+}
+
 func (p *podStartupLatencyMeasurement) printLatencies(latencies []measurementutil.LatencyData, header string) {
 	metrics := measurementutil.NewLatencyMetric(latencies)
 	index := len(latencies) - 100
@@ -309,6 +366,8 @@ type podLatencyData struct {
 	Name    string
 	Node    string
 	Latency time.Duration
+	// ChaosEvents are types of chaos events overlapping with the pod startup.
+	ChaosEvents []string
 }
 
 func (p podLatencyData) GetLatency() time.Duration {
@@ -321,6 +380,9 @@ type podStartupLatency struct {
 	RunToWatchLatency       measurementutil.LatencyMetric `json:"runToWatchLatency"`
 	ScheduleToWatchLatency  measurementutil.LatencyMetric `json:"scheduleToWatchLatency"`
 	E2ELatency              measurementutil.LatencyMetric `json:"e2eLatency"`
+	// E2EChaosLatency and E2EBaselineLatency are set only if chaos events are annotated.
+	E2EChaosLatency    *measurementutil.LatencyMetric `json:"e2eChaosLatency,omitempty"`
+	E2EBaselineLatency *measurementutil.LatencyMetric `json:"e2eBaselineLatency,omitempty"`
 }
 
 func podStartupLatencyToPerfData(latency *podStartupLatency) *measurementutil.PerfData {
@@ -330,6 +392,12 @@ func podStartupLatencyToPerfData(latency *podStartupLatency) *measurementutil.Pe
 	perfData.DataItems = append(perfData.DataItems, latency.RunToWatchLatency.ToPerfData("run_to_watch"))
 	perfData.DataItems = append(perfData.DataItems, latency.ScheduleToWatchLatency.ToPerfData("schedule_to_watch"))
 	perfData.DataItems = append(perfData.DataItems, latency.E2ELatency.ToPerfData("pod_startup"))
+	if latency.E2EChaosLatency != nil {
+		perfData.DataItems = append(perfData.DataItems, latency.E2EChaosLatency.ToPerfData("pod_startup_during_chaos"))
+	}
+	if latency.E2EBaselineLatency != nil {
+		perfData.DataItems = append(perfData.DataItems, latency.E2EBaselineLatency.ToPerfData("pod_startup_baseline"))
+	}
 	return perfData
 }
 
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/perf-tests/clusterloader2/pkg/chaos"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+func TestSplitByChaosEvents(t *testing.T) {
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+	end := func(seconds int) *time.Time {
+		t := at(seconds)
+		return &t
+	}
+	createTimes := map[string]metav1.Time{
+		"ns/before":     metav1.NewTime(at(0)),
+		"ns/during":     metav1.NewTime(at(10)),
+		"ns/after":      metav1.NewTime(at(30)),
+		"ns/unrepaired": metav1.NewTime(at(50)),
+	}
+	e2eLag := []measurementutil.LatencyData{
+		podLatencyData{Name: "ns/before", Latency: 5 * time.Second},
+		podLatencyData{Name: "ns/during", Latency: 5 * time.Second},
+		podLatencyData{Name: "ns/after", Latency: 5 * time.Second},
+		podLatencyData{Name: "ns/unrepaired", Latency: 5 * time.Second},
+	}
+	events := []chaos.Event{
+		{Type: "NodeFailure", Start: at(8), End: end(20)},
+		{Type: "PodFailure", Start: at(12), End: end(12)},
+		{Type: "NetworkPartition", Start: at(45)},
+	}
+
+	chaosLag, baselineLag := splitByChaosEvents(e2eLag, createTimes, events)
+
+	assert.Equal(t, []measurementutil.LatencyData{
+		podLatencyData{Name: "ns/during", Latency: 5 * time.Second, ChaosEvents: []string{"NodeFailure", "PodFailure"}},
+		podLatencyData{Name: "ns/unrepaired", Latency: 5 * time.Second, ChaosEvents: []string{"NetworkPartition"}},
+	}, chaosLag)
+	assert.Equal(t, []measurementutil.LatencyData{
+		podLatencyData{Name: "ns/before", Latency: 5 * time.Second},
+		podLatencyData{Name: "ns/after", Latency: 5 * time.Second},
+	}, baselineLag)
+	// Annotations are visible in all e2e latencies.
+	assert.Equal(t, []string{"NodeFailure", "PodFailure"}, e2eLag[1].(podLatencyData).ChaosEvents)
+}
@@ -47,6 +47,13 @@ type Gatherer interface {
 	String() string
 }
 
+// ConfigurableGatherer is a Gatherer configured with measurement params.
+// Configure is called before every Gather.
+type ConfigurableGatherer interface {
+	Gatherer
+	Configure(config *measurement.MeasurementConfig) error
+}
+
 type prometheusMeasurement struct {
 	name     string
 	gatherer Gatherer
@@ -55,7 +62,7 @@ type prometheusMeasurement struct {
 }
 
 func (m *prometheusMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
-	if config.PrometheusFramework == nil {
+	if config.PrometheusClient == nil {
 		klog.Warningf("%s: Prometheus is disable, skipping the measurement!", m)
 		return nil, nil
 	}
@@ -77,8 +84,13 @@ func (m *prometheusMeasurement) Execute(config *measurement.MeasurementConfig) (
 			return nil, err
 		}
 
-		c := config.PrometheusFramework.GetClientSets().GetClient()
-		executor := measurementutil.NewQueryExecutor(c)
+		if configurable, ok := m.gatherer.(ConfigurableGatherer); ok {
+			if err := configurable.Configure(config); err != nil {
+				return nil, err
+			}
+		}
+
+		executor := measurementutil.NewQueryExecutor(config.PrometheusClient)
 
 		summary, err := m.gatherer.Gather(executor, m.startTime)
 		if err != nil {
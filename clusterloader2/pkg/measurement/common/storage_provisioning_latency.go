@@ -0,0 +1,440 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/informer"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	storageProvisioningLatencyMeasurementName = "StorageProvisioningLatency"
+
+	provisionedByAnnotation     = "pv.kubernetes.io/provisioned-by"
+	betaStorageClassAnnotation  = "volume.beta.kubernetes.io/storage-class"
+	successfulAttachVolumeEvent = "SuccessfulAttachVolume"
+
+	defaultStorageClassLabel = "default"
+	noStorageClassLabel      = "none"
+)
+
+func init() {
+	if err := measurement.Register(storageProvisioningLatencyMeasurementName, createStorageProvisioningLatencyMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", storageProvisioningLatencyMeasurementName, err)
+	}
+}
+
+func createStorageProvisioningLatencyMeasurement() measurement.Measurement {
+	return &storageProvisioningLatencyMeasurement{
+		claims: make(map[string]*claimTimes),
+		pods:   make(map[string]*podVolumeTimes),
+	}
+}
+
+// claimTimes represents lifecycle of a single persistent volume claim.
+type claimTimes struct {
+	storageClass string
+	created      metav1.Time
+	bound        metav1.Time
+	provisioned  metav1.Time
+}
+
+// podVolumeTimes represents lifecycle of volumes of a single pod.
+type podVolumeTimes struct {
+	claims    []string
+	scheduled metav1.Time
+	attached  metav1.Time
+	running   metav1.Time
+}
+
+type storageProvisioningLatencyMeasurement struct {
+	namespace        string
+	labelSelector    string
+	podLabelSelector string
+	bindThreshold    time.Duration
+	podThreshold     time.Duration
+	startTime        time.Time
+	isRunning        bool
+	stopCh           chan struct{}
+
+	lock            sync.Mutex
+	claims          map[string]*claimTimes
+	pods            map[string]*podVolumeTimes
+	selectorsString string
+}
+
+// Execute supports two actions:
+// - start - Starts to observe persistent volume claims, persistent volumes and pods.
+// - gather - Gathers and prints storage provisioning latency data.
+// Only objects created after start are taken into account.
+// Does NOT support concurrency. Multiple calls to this measurement
+// shouldn't be done within one step.
+func (s *storageProvisioningLatencyMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "start":
+		s.namespace, err = util.GetStringOrDefault(config.Params, "namespace", metav1.NamespaceAll)
+		if err != nil {
+			return nil, err
+		}
+		s.labelSelector, err = util.GetStringOrDefault(config.Params, "labelSelector", "")
+		if err != nil {
+			return nil, err
+		}
+		s.podLabelSelector, err = util.GetStringOrDefault(config.Params, "podLabelSelector", "")
+		if err != nil {
+			return nil, err
+		}
+		s.bindThreshold, err = util.GetDurationOrDefault(config.Params, "threshold", 0)
+		if err != nil {
+			return nil, err
+		}
+		s.podThreshold, err = util.GetDurationOrDefault(config.Params, "podThreshold", 0)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.start(config.ClusterFramework.GetClientSets().GetClient())
+	case "gather":
+		return s.gather(config.ClusterFramework.GetClientSets().GetClient(), config.Identifier)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (s *storageProvisioningLatencyMeasurement) Dispose() {
+	s.stop()
+}
+
+// String returns string representation of this measurement.
+func (s *storageProvisioningLatencyMeasurement) String() string {
+	return storageProvisioningLatencyMeasurementName + ": " + s.selectorsString
+}
+
+func (s *storageProvisioningLatencyMeasurement) start(c clientset.Interface) error {
+	if s.isRunning {
+		klog.Infof("%s: storage provisioning latency measurement already running", s)
+		return nil
+	}
+	s.selectorsString = measurementutil.CreateSelectorsString(s.namespace, s.labelSelector, "")
+	klog.Infof("%s: starting storage provisioning latency measurement...", s)
+	s.isRunning = true
+	s.startTime = time.Now()
+	s.stopCh = make(chan struct{})
+	informers := []struct {
+		kind          string
+		namespace     string
+		labelSelector string
+		handleObj     func(interface{}, interface{})
+	}{
+		{kind: "persistentvolumeclaims", namespace: s.namespace, labelSelector: s.labelSelector, handleObj: s.checkClaim},
+		{kind: "persistentvolumes", handleObj: s.checkVolume},
+		{kind: "pods", namespace: s.namespace, labelSelector: s.podLabelSelector, handleObj: s.checkPod},
+	}
+	for _, inf := range informers {
+		i := informer.NewInformer(c, inf.kind, inf.namespace, "", inf.labelSelector, inf.handleObj)
+		if err := informer.StartAndSync(i, s.stopCh, informerSyncTimeout); err != nil {
+			return fmt.Errorf("%s informer: %v", inf.kind, err)
+		}
+	}
+	return nil
+}
+
+func (s *storageProvisioningLatencyMeasurement) stop() {
+	if s.isRunning {
+		s.isRunning = false
+		close(s.stopCh)
+	}
+}
+
+func (s *storageProvisioningLatencyMeasurement) gather(c clientset.Interface, identifier string) ([]measurement.Summary, error) {
+	klog.Infof("%s: gathering storage provisioning latency measurement...", s)
+	if !s.isRunning {
+		return nil, fmt.Errorf("metric %s has not been started", storageProvisioningLatencyMeasurementName)
+	}
+	s.stop()
+
+	if err := s.gatherAttachTimes(c); err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	latencies := computeStorageLatencies(s.claims, s.pods)
+	s.lock.Unlock()
+
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	var violations []string
+	for _, l := range latencies {
+		klog.Infof("%s: storage class %q, %s: %v", s, l.storageClass, l.metric, l.latency)
+		perfData.DataItems = append(perfData.DataItems, l.toPerfData())
+		threshold := time.Duration(0)
+		switch l.metric {
+		case claimToBoundMetric:
+			threshold = s.bindThreshold
+		case scheduleToRunningMetric:
+			threshold = s.podThreshold
+		}
+		if threshold == 0 {
+			continue
+		}
+		if err := l.latency.VerifyThreshold(&measurementutil.LatencyMetric{Perc50: threshold, Perc90: threshold, Perc99: threshold}); err != nil {
+			violations = append(violations, fmt.Sprintf("storage class %q %s: %v", l.storageClass, l.metric, err))
+		}
+	}
+
+	var err error
+	if len(violations) > 0 {
+		err = errors.NewMetricViolationError("storage provisioning", strings.Join(violations, "; "))
+		klog.Errorf("%s: %v", s, err)
+	}
+
+	content, jsonErr := util.PrettyPrintJSON(perfData)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", storageProvisioningLatencyMeasurementName, identifier), "json", content)
+	return []measurement.Summary{summary}, err
+}
+
+// gatherAttachTimes sets attach time of tracked pods based on events emitted
+// by attach/detach controller. Pods with many volumes are attached when the last one is.
+func (s *storageProvisioningLatencyMeasurement) gatherAttachTimes(c clientset.Interface) error {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"reason":              successfulAttachVolumeEvent,
+	}.AsSelector().String()
+	options := metav1.ListOptions{FieldSelector: selector}
+	attachEvents, err := c.CoreV1().Events(s.namespace).List(options)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.mergeAttachEvents(attachEvents.Items)
+	return nil
+}
+
+// mergeAttachEvents sets attach time of tracked pods to the time of their latest attach event.
+// Caller has to hold the lock.
+func (s *storageProvisioningLatencyMeasurement) mergeAttachEvents(events []corev1.Event) {
+	for _, event := range events {
+		key := event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		pod, ok := s.pods[key]
+		if !ok {
+			continue
+		}
+		eventTime := event.FirstTimestamp
+		if !event.EventTime.IsZero() {
+			eventTime = (metav1.Time)(event.EventTime)
+		}
+		if pod.attached.Before(&eventTime) {
+			pod.attached = eventTime
+		}
+	}
+}
+
+func (s *storageProvisioningLatencyMeasurement) checkClaim(_, obj interface{}) {
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok || pvc.CreationTimestamp.Time.Before(s.startTime) {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := pvc.Namespace + "/" + pvc.Name
+	claim, ok := s.claims[key]
+	if !ok {
+		claim = &claimTimes{}
+		s.claims[key] = claim
+	}
+	claim.created = pvc.CreationTimestamp
+	claim.storageClass = getStorageClassLabel(pvc)
+	if pvc.Status.Phase == corev1.ClaimBound && claim.bound.IsZero() {
+		claim.bound = metav1.Now()
+	}
+}
+
+func (s *storageProvisioningLatencyMeasurement) checkVolume(_, obj interface{}) {
+	pv, ok := obj.(*corev1.PersistentVolume)
+	if !ok || pv.Spec.ClaimRef == nil || pv.CreationTimestamp.Time.Before(s.startTime) {
+		return
+	}
+	if _, ok := pv.Annotations[provisionedByAnnotation]; !ok {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+	claim, ok := s.claims[key]
+	if !ok {
+		// Volume can be observed before the claim.
+		claim = &claimTimes{}
+		s.claims[key] = claim
+	}
+	claim.provisioned = pv.CreationTimestamp
+}
+
+func (s *storageProvisioningLatencyMeasurement) checkPod(_, obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.CreationTimestamp.Time.Before(s.startTime) {
+		return
+	}
+	var claims []string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims = append(claims, pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	if len(claims) == 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := pod.Namespace + "/" + pod.Name
+	times, ok := s.pods[key]
+	if !ok {
+		times = &podVolumeTimes{claims: claims}
+		s.pods[key] = times
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionTrue {
+			times.scheduled = condition.LastTransitionTime
+		}
+	}
+	if pod.Status.Phase == corev1.PodRunning && times.running.IsZero() {
+		times.running = metav1.Now()
+	}
+}
+
+func getStorageClassLabel(pvc *corev1.PersistentVolumeClaim) string {
+	if class, ok := pvc.Annotations[betaStorageClassAnnotation]; ok {
+		if class == "" {
+			return noStorageClassLabel
+		}
+		return class
+	}
+	if pvc.Spec.StorageClassName == nil {
+		return defaultStorageClassLabel
+	}
+	if *pvc.Spec.StorageClassName == "" {
+		return noStorageClassLabel
+	}
+	return *pvc.Spec.StorageClassName
+}
+
+const (
+	claimToBoundMetric       = "claim_to_bound"
+	claimToProvisionedMetric = "claim_to_provisioned"
+	scheduleToAttachMetric   = "schedule_to_attach"
+	scheduleToRunningMetric  = "schedule_to_running"
+)
+
+type storageLatency struct {
+	storageClass string
+	metric       string
+	count        int
+	latency      measurementutil.LatencyMetric
+}
+
+func (l *storageLatency) toPerfData() measurementutil.DataItem {
+	item := l.latency.ToPerfData(l.metric)
+	item.Labels["StorageClass"] = l.storageClass
+	item.Data["Count"] = float64(l.count)
+	return item
+}
+
+type storageLatencyData struct {
+	Name    string
+	Latency time.Duration
+}
+
+func (d storageLatencyData) GetLatency() time.Duration {
+	return d.Latency
+}
+
+// computeStorageLatencies computes latencies of every phase broken down by storage class.
+// Pods are assigned storage class of the first of their claims.
+// Result is sorted by storage class and metric.
+func computeStorageLatencies(claims map[string]*claimTimes, pods map[string]*podVolumeTimes) []*storageLatency {
+	data := make(map[string]map[string][]measurementutil.LatencyData)
+	add := func(storageClass, metric, name string, from, to metav1.Time) {
+		if from.IsZero() || to.IsZero() {
+			return
+		}
+		if _, ok := data[storageClass]; !ok {
+			data[storageClass] = make(map[string][]measurementutil.LatencyData)
+		}
+		data[storageClass][metric] = append(data[storageClass][metric], storageLatencyData{Name: name, Latency: to.Time.Sub(from.Time)})
+	}
+
+	for key, claim := range claims {
+		if claim.created.IsZero() {
+			klog.Infof("%s: claim %v of volume provisioned during measurement wasn't observed", storageProvisioningLatencyMeasurementName, key)
+			continue
+		}
+		if claim.bound.IsZero() {
+			klog.Infof("%s: claim %v hasn't been bound", storageProvisioningLatencyMeasurementName, key)
+		}
+		add(claim.storageClass, claimToBoundMetric, key, claim.created, claim.bound)
+		add(claim.storageClass, claimToProvisionedMetric, key, claim.created, claim.provisioned)
+	}
+	for key, pod := range pods {
+		claim, ok := claims[pod.claims[0]]
+		if !ok || claim.created.IsZero() {
+			continue
+		}
+		add(claim.storageClass, scheduleToAttachMetric, key, pod.scheduled, pod.attached)
+		add(claim.storageClass, scheduleToRunningMetric, key, pod.scheduled, pod.running)
+	}
+
+	var result []*storageLatency
+	for storageClass, metrics := range data {
+		for metric, latencies := range metrics {
+			sort.Sort(measurementutil.LatencySlice(latencies))
+			result = append(result, &storageLatency{
+				storageClass: storageClass,
+				metric:       metric,
+				count:        len(latencies),
+				latency:      measurementutil.NewLatencyMetric(latencies),
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].storageClass != result[j].storageClass {
+			return result[i].storageClass < result[j].storageClass
+		}
+		return result[i].metric < result[j].metric
+	})
+	return result
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+func TestGetStorageClassLabel(t *testing.T) {
+	standard, empty := "standard", ""
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		className   *string
+		want        string
+	}{
+		{name: "storage class name", className: &standard, want: "standard"},
+		{name: "no storage class name", want: defaultStorageClassLabel},
+		{name: "empty storage class name", className: &empty, want: noStorageClassLabel},
+		{name: "beta annotation", annotations: map[string]string{betaStorageClassAnnotation: "fast"}, className: &standard, want: "fast"},
+		{name: "empty beta annotation", annotations: map[string]string{betaStorageClassAnnotation: ""}, want: noStorageClassLabel},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: c.className},
+			}
+			assert.Equal(t, c.want, getStorageClassLabel(pvc))
+		})
+	}
+}
+
+func TestComputeStorageLatencies(t *testing.T) {
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time { return metav1.NewTime(base.Add(time.Duration(seconds) * time.Second)) }
+	claims := map[string]*claimTimes{
+		"ns/fast-1":   {storageClass: "fast", created: at(0), bound: at(2), provisioned: at(1)},
+		"ns/fast-2":   {storageClass: "fast", created: at(0), bound: at(4), provisioned: at(3)},
+		"ns/standard": {storageClass: "standard", created: at(0), bound: at(10)},
+		// Claim of the volume provisioned during measurement, which wasn't observed.
+		"ns/unknown": {provisioned: at(1)},
+		// Claim which hasn't been bound.
+		"ns/pending": {storageClass: "standard", created: at(0)},
+	}
+	pods := map[string]*podVolumeTimes{
+		"ns/pod-1": {claims: []string{"ns/fast-1", "ns/standard"}, scheduled: at(5), attached: at(7), running: at(10)},
+		// Not attached yet.
+		"ns/pod-2": {claims: []string{"ns/standard"}, scheduled: at(5), running: at(20)},
+		// Claim isn't tracked.
+		"ns/pod-3": {claims: []string{"ns/untracked"}, scheduled: at(5), running: at(6)},
+		"ns/pod-4": {claims: []string{"ns/unknown"}, scheduled: at(5), running: at(6)},
+	}
+
+	latencies := computeStorageLatencies(claims, pods)
+
+	type result struct {
+		storageClass string
+		metric       string
+		count        int
+		latency      measurementutil.LatencyMetric
+	}
+	var got []result
+	for _, l := range latencies {
+		got = append(got, result{l.storageClass, l.metric, l.count, l.latency})
+	}
+	latency := func(perc50, perc90, perc99 int) measurementutil.LatencyMetric {
+		return measurementutil.LatencyMetric{
+			Perc50: time.Duration(perc50) * time.Second,
+			Perc90: time.Duration(perc90) * time.Second,
+			Perc99: time.Duration(perc99) * time.Second,
+		}
+	}
+	assert.Equal(t, []result{
+		{"fast", claimToBoundMetric, 2, latency(2, 4, 4)},
+		{"fast", claimToProvisionedMetric, 2, latency(1, 3, 3)},
+		{"fast", scheduleToAttachMetric, 1, latency(2, 2, 2)},
+		{"fast", scheduleToRunningMetric, 1, latency(5, 5, 5)},
+		{"standard", claimToBoundMetric, 1, latency(10, 10, 10)},
+		{"standard", scheduleToRunningMetric, 1, latency(15, 15, 15)},
+	}, got)
+}
+
+func TestMergeAttachEvents(t *testing.T) {
+	base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time { return metav1.NewTime(base.Add(time.Duration(seconds) * time.Second)) }
+	attachEvent := func(pod string, firstTimestamp, eventTime metav1.Time) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: pod},
+			Reason:         successfulAttachVolumeEvent,
+			FirstTimestamp: firstTimestamp,
+			EventTime:      metav1.MicroTime(eventTime),
+		}
+	}
+	s := &storageProvisioningLatencyMeasurement{
+		pods: map[string]*podVolumeTimes{
+			"ns/multi-volume": {claims: []string{"ns/a", "ns/b"}},
+			"ns/event-time":   {claims: []string{"ns/c"}},
+			"ns/no-events":    {claims: []string{"ns/d"}},
+		},
+	}
+
+	s.mergeAttachEvents([]corev1.Event{
+		// Pod with many volumes is attached when the last one is.
+		attachEvent("multi-volume", at(3), metav1.Time{}),
+		attachEvent("multi-volume", at(5), metav1.Time{}),
+		attachEvent("multi-volume", at(4), metav1.Time{}),
+		// EventTime takes precedence over FirstTimestamp.
+		attachEvent("event-time", at(1), at(2)),
+		// Events of untracked pods are ignored.
+		attachEvent("untracked", at(1), metav1.Time{}),
+	})
+
+	assert.Equal(t, at(5), s.pods["ns/multi-volume"].attached)
+	assert.Equal(t, at(2), s.pods["ns/event-time"].attached)
+	assert.True(t, s.pods["ns/no-events"].attached.IsZero())
+	assert.Len(t, s.pods, 3)
+}
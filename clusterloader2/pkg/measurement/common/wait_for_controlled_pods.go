@@ -75,6 +75,9 @@ type waitForControlledPodsRunningMeasurement struct {
 	lock              sync.Mutex
 	opResourceVersion uint64
 	gvr               schema.GroupVersionResource
+	resource          string
+	replicasPath      string
+	selectorPath      string
 	checkerMap        map[string]*objectChecker
 	clusterFramework  *framework.Framework
 }
@@ -84,6 +87,10 @@ type waitForControlledPodsRunningMeasurement struct {
 // If namespace is not passed by parameter, all-namespace scope is assumed.
 // "Start" action starts observation of the controlling objects, while "gather" waits for until
 // specified number of controlling objects have all pods running.
+// Any kind of controlling objects (including custom resources) is supported.
+// Replicas and selector are read from spec.replicas and spec.selector fields by default,
+// which can be changed with replicasPath and selectorPath params (JSONPath, e.g. {.spec.size}).
+// Resource name is guessed from kind, unless resource param is specified.
 func (w *waitForControlledPodsRunningMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
 	w.clusterFramework = config.ClusterFramework
 
@@ -118,6 +125,18 @@ func (w *waitForControlledPodsRunningMeasurement) Execute(config *measurement.Me
 		if err != nil {
 			return nil, err
 		}
+		w.resource, err = util.GetStringOrDefault(config.Params, "resource", "")
+		if err != nil {
+			return nil, err
+		}
+		w.replicasPath, err = util.GetStringOrDefault(config.Params, "replicasPath", "")
+		if err != nil {
+			return nil, err
+		}
+		w.selectorPath, err = util.GetStringOrDefault(config.Params, "selectorPath", "")
+		if err != nil {
+			return nil, err
+		}
 		return nil, w.start()
 	case "gather":
 		syncTimeout, err := util.GetDurationOrDefault(config.Params, "syncTimeout", defaultSyncTimeout)
@@ -162,6 +181,9 @@ func (w *waitForControlledPodsRunningMeasurement) start() error {
 	}
 	gvk := gv.WithKind(w.kind)
 	w.gvr, _ = meta.UnsafeGuessKindToResource(gvk)
+	if w.resource != "" {
+		w.gvr = gv.WithResource(w.resource)
+	}
 
 	w.isRunning = true
 	w.stopCh = make(chan struct{})
@@ -290,12 +312,12 @@ func (w *waitForControlledPodsRunningMeasurement) handleObject(oldObj, newObj in
 	}
 }
 
-func checkScaledown(oldObj, newObj runtime.Object) (bool, error) {
-	oldReplicas, err := runtimeobjects.GetReplicasFromRuntimeObject(oldObj)
+func checkScaledown(oldObj, newObj runtime.Object, replicasPath string) (bool, error) {
+	oldReplicas, err := runtimeobjects.GetReplicasFromRuntimeObjectWithJSONPath(oldObj, replicasPath)
 	if err != nil {
 		return false, err
 	}
-	newReplicas, err := runtimeobjects.GetReplicasFromRuntimeObject(newObj)
+	newReplicas, err := runtimeobjects.GetReplicasFromRuntimeObjectWithJSONPath(newObj, replicasPath)
 	if err != nil {
 		return false, err
 	}
@@ -305,7 +327,7 @@ func checkScaledown(oldObj, newObj runtime.Object) (bool, error) {
 
 func (w *waitForControlledPodsRunningMeasurement) handleObjectLocked(oldObj, newObj runtime.Object) error {
 	isObjDeleted := newObj == nil
-	isScalingDown, err := checkScaledown(oldObj, newObj)
+	isScalingDown, err := checkScaledown(oldObj, newObj, w.replicasPath)
 	if err != nil {
 		return fmt.Errorf("checkScaledown error: %v", err)
 	}
@@ -376,7 +398,7 @@ func (w *waitForControlledPodsRunningMeasurement) updateOpResourceVersion(runtim
 func (w *waitForControlledPodsRunningMeasurement) getObjectCountAndMaxVersion() (int, uint64, error) {
 	var desiredCount int
 	var maxResourceVersion uint64
-	objects, err := runtimeobjects.ListRuntimeObjectsForKind(w.clusterFramework.GetClientSets().GetClient(), w.kind, w.namespace, w.labelSelector, w.fieldSelector)
+	objects, err := runtimeobjects.ListRuntimeObjectsForResource(w.clusterFramework.GetDynamicClients().GetClient(), w.gvr, w.namespace, w.labelSelector, w.fieldSelector)
 	if err != nil {
 		return desiredCount, maxResourceVersion, fmt.Errorf("listing objects error: %v", err)
 	}
@@ -405,11 +427,11 @@ func (w *waitForControlledPodsRunningMeasurement) waitForRuntimeObject(obj runti
 	if err != nil {
 		return nil, err
 	}
-	runtimeObjectSelector, err := runtimeobjects.GetSelectorFromRuntimeObject(obj)
+	runtimeObjectSelector, err := runtimeobjects.GetSelectorFromRuntimeObjectWithJSONPath(obj, w.selectorPath)
 	if err != nil {
 		return nil, err
 	}
-	runtimeObjectReplicas, err := runtimeobjects.GetReplicasFromRuntimeObject(obj)
+	runtimeObjectReplicas, err := runtimeobjects.GetReplicasFromRuntimeObjectWithJSONPath(obj, w.replicasPath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,364 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/util"
+)
+
+const (
+	watchLatencyMeasurementName = "WatchLatency"
+	defaultWatchersPerResource  = 1
+	watchRetryInterval          = 5 * time.Second
+	// clientStampedLatencyMetric is the name of the reported latency metric. Latency is measured
+	// from the client-side timestamp, as objects don't carry server time with sub-second precision.
+	clientStampedLatencyMetric = "ClientStampedWatchLatency"
+)
+
+func init() {
+	if err := measurement.Register(watchLatencyMeasurementName, createWatchLatencyMeasurement); err != nil {
+		klog.Fatalf("Cannot register %s: %v", watchLatencyMeasurementName, err)
+	}
+}
+
+func createWatchLatencyMeasurement() measurement.Measurement {
+	return &watchLatencyMeasurement{}
+}
+
+type watchLatencyMeasurement struct {
+	isRunning bool
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	resources []*watchedResource
+}
+
+// Execute supports two actions:
+// - start - starts watchers for given resources.
+//   Resources are specified as a list of apiVersion and kind pairs (pods are watched by default).
+//   For every resource, watchersPerResource watchers are spread across the available clients.
+//   Objects can be specified by field and/or label selectors.
+//   If namespace is not passed by parameter, all-namespace scope is assumed.
+// - gather - stops watchers and creates summary for observed latencies.
+// Latency is measured as the time between the create or patch call issued by the test executor
+// (recorded in the object's annotation) and the arrival of the corresponding watch event.
+// The time is recorded by the client before the call, so the latency includes client-side
+// throttling and the api call itself, not only the watch delivery.
+func (w *watchLatencyMeasurement) Execute(config *measurement.MeasurementConfig) ([]measurement.Summary, error) {
+	action, err := util.GetString(config.Params, "action")
+	if err != nil {
+		return nil, err
+	}
+	switch action {
+	case "start":
+		if w.isRunning {
+			klog.Infof("%s: measurement already running", w)
+			return nil, nil
+		}
+		namespace, err := util.GetStringOrDefault(config.Params, "namespace", metav1.NamespaceAll)
+		if err != nil {
+			return nil, err
+		}
+		labelSelector, err := util.GetStringOrDefault(config.Params, "labelSelector", "")
+		if err != nil {
+			return nil, err
+		}
+		fieldSelector, err := util.GetStringOrDefault(config.Params, "fieldSelector", "")
+		if err != nil {
+			return nil, err
+		}
+		watchersPerResource, err := util.GetIntOrDefault(config.Params, "watchersPerResource", defaultWatchersPerResource)
+		if err != nil {
+			return nil, err
+		}
+		defaultResources := []map[string]interface{}{{"apiVersion": "v1", "kind": "Pod"}}
+		resources, err := util.GetMapArrayOrDefault(config.Params, "resources", defaultResources)
+		if err != nil {
+			return nil, err
+		}
+		options := metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
+		return nil, w.start(config.ClusterFramework, resources, watchersPerResource, namespace, options)
+	case "gather":
+		return w.gather(config.Identifier)
+	default:
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+}
+
+// Dispose cleans up after the measurement.
+func (w *watchLatencyMeasurement) Dispose() {
+	w.stop()
+}
+
+// String returns a string representation of the measurement.
+func (*watchLatencyMeasurement) String() string {
+	return watchLatencyMeasurementName
+}
+
+func (w *watchLatencyMeasurement) start(f *framework.Framework, resources []map[string]interface{}, watchersPerResource int, namespace string, options metav1.ListOptions) error {
+	if watchersPerResource < 1 {
+		return fmt.Errorf("incorrect watchersPerResource: %d", watchersPerResource)
+	}
+	w.resources = nil
+	for _, resource := range resources {
+		apiVersion, err := util.GetString(resource, "apiVersion")
+		if err != nil {
+			return err
+		}
+		kind, err := util.GetString(resource, "kind")
+		if err != nil {
+			return err
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return err
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(kind))
+		w.resources = append(w.resources, &watchedResource{gvr: gvr})
+	}
+
+	klog.Infof("%s: starting %d watchers per resource", w, watchersPerResource)
+	w.isRunning = true
+	w.stopCh = make(chan struct{})
+	for _, resource := range w.resources {
+		for i := 0; i < watchersPerResource; i++ {
+			w.wg.Add(1)
+			go w.runWatcher(f.GetDynamicClients().GetClient(), resource, namespace, options)
+		}
+	}
+	return nil
+}
+
+func (w *watchLatencyMeasurement) stop() {
+	if w.isRunning {
+		w.isRunning = false
+		close(w.stopCh)
+		w.wg.Wait()
+	}
+}
+
+func (w *watchLatencyMeasurement) gather(identifier string) ([]measurement.Summary, error) {
+	if !w.isRunning {
+		return nil, fmt.Errorf("metric %s has not been started", w)
+	}
+	w.stop()
+	klog.Infof("%s: gathering data", w)
+
+	perfData := &measurementutil.PerfData{Version: "v1"}
+	for _, resource := range w.resources {
+		resource.lock.Lock()
+		sort.Sort(measurementutil.LatencySlice(resource.latencies))
+		latency := measurementutil.NewLatencyMetric(resource.latencies)
+		klog.Infof("%s: %v: %d events, %d dropped watches, %d relisted watches, %d rewatched watches, latency: %v",
+			w, resource.gvr, len(resource.latencies), resource.dropped, resource.relisted, resource.rewatched, latency)
+
+		latencyItem := latency.ToPerfData(clientStampedLatencyMetric)
+		latencyItem.Labels["Resource"] = resource.gvr.String()
+		countItem := measurementutil.DataItem{
+			Data: map[string]float64{
+				"Events":    float64(len(resource.latencies)),
+				"Dropped":   float64(resource.dropped),
+				"Relisted":  float64(resource.relisted),
+				"Rewatched": float64(resource.rewatched),
+			},
+			Unit: "count",
+			Labels: map[string]string{
+				"Metric":   "WatchEvents",
+				"Resource": resource.gvr.String(),
+			},
+		}
+		perfData.DataItems = append(perfData.DataItems, latencyItem, countItem)
+		resource.lock.Unlock()
+	}
+
+	content, err := util.PrettyPrintJSON(perfData)
+	if err != nil {
+		return nil, err
+	}
+	summary := measurement.CreateSummary(fmt.Sprintf("%s_%s", watchLatencyMeasurementName, identifier), "json", content)
+	return []measurement.Summary{summary}, nil
+}
+
+// runWatcher lists and watches given resource until the measurement is stopped.
+// Closed watches are reopened from the last observed resource version,
+// expired watches are reestablished with a new list.
+func (w *watchLatencyMeasurement) runWatcher(c dynamic.Interface, resource *watchedResource, namespace string, options metav1.ListOptions) {
+	defer w.wg.Done()
+	// lastSeen stores the latest timestamp observed for every object, so that only
+	// the first event carrying a given timestamp is taken into account.
+	lastSeen := make(map[string]time.Time)
+	resourceVersion := ""
+	for {
+		if resourceVersion == "" {
+			list, err := c.Resource(resource.gvr).Namespace(namespace).List(options)
+			if err != nil {
+				klog.Errorf("%s: listing %v error: %v", w, resource.gvr, err)
+				if !w.sleep(watchRetryInterval) {
+					return
+				}
+				continue
+			}
+			for i := range list.Items {
+				if t, ok, _ := client.GetTimestampAnnotation(&list.Items[i]); ok {
+					lastSeen[getWatchKey(&list.Items[i])] = t
+				}
+			}
+			resourceVersion = list.GetResourceVersion()
+		}
+
+		watchOptions := options
+		watchOptions.ResourceVersion = resourceVersion
+		watcher, err := c.Resource(resource.gvr).Namespace(namespace).Watch(watchOptions)
+		if err != nil {
+			klog.Errorf("%s: watching %v error: %v", w, resource.gvr, err)
+			if !w.sleep(watchRetryInterval) {
+				return
+			}
+			continue
+		}
+		var stopped bool
+		resourceVersion, stopped = w.handleEvents(watcher, resource, lastSeen, resourceVersion)
+		if stopped {
+			return
+		}
+	}
+}
+
+// handleEvents records latencies of the events delivered by the watcher.
+// It returns the last observed resource version (empty if relist is required)
+// and whether the measurement has been stopped.
+func (w *watchLatencyMeasurement) handleEvents(watcher watch.Interface, resource *watchedResource, lastSeen map[string]time.Time, resourceVersion string) (string, bool) {
+	defer watcher.Stop()
+	for {
+		select {
+		case <-w.stopCh:
+			return resourceVersion, true
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				// Watch closed without an error (e.g. due to the server timeout) is just reopened.
+				resource.addRewatched()
+				return resourceVersion, false
+			}
+			if event.Type == watch.Error {
+				err := apierrs.FromObject(event.Object)
+				if apierrs.IsResourceExpired(err) || apierrs.IsGone(err) {
+					resource.addRelisted()
+					return "", false
+				}
+				klog.Errorf("%s: %v watch error: %v", w, resource.gvr, err)
+				resource.addDropped()
+				return resourceVersion, false
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			resourceVersion = obj.GetResourceVersion()
+			key := getWatchKey(obj)
+			if event.Type == watch.Deleted {
+				delete(lastSeen, key)
+				continue
+			}
+			t, ok, err := client.GetTimestampAnnotation(obj)
+			if err != nil {
+				klog.Errorf("%s: %v", w, err)
+				continue
+			}
+			if !ok || lastSeen[key].Equal(t) {
+				continue
+			}
+			lastSeen[key] = t
+			resource.addLatency(watchLatencyData{Name: key, Latency: time.Since(t)})
+		}
+	}
+}
+
+// sleep waits for the given duration. It returns false if the measurement has been stopped in the meantime.
+func (w *watchLatencyMeasurement) sleep(d time.Duration) bool {
+	select {
+	case <-w.stopCh:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func getWatchKey(obj metav1.Object) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+type watchedResource struct {
+	gvr schema.GroupVersionResource
+
+	lock      sync.Mutex
+	latencies []measurementutil.LatencyData
+	// dropped counts watches closed with an error.
+	dropped int
+	// relisted counts watches that expired and required a new list.
+	relisted int
+	// rewatched counts watches closed without an error.
+	rewatched int
+}
+
+func (r *watchedResource) addLatency(latency measurementutil.LatencyData) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.latencies = append(r.latencies, latency)
+}
+
+func (r *watchedResource) addDropped() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.dropped++
+}
+
+func (r *watchedResource) addRewatched() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.rewatched++
+}
+
+func (r *watchedResource) addRelisted() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.relisted++
+}
+
+type watchLatencyData struct {
+	Name    string
+	Latency time.Duration
+}
+
+func (w watchLatencyData) GetLatency() time.Duration {
+	return w.Latency
+}
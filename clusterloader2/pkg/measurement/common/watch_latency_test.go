@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+)
+
+func newWatchedObject(name, resourceVersion string, timestamp time.Time) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}}
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion(resourceVersion)
+	if !timestamp.IsZero() {
+		client.SetTimestampAnnotation(obj, timestamp)
+	}
+	return obj
+}
+
+func TestWatchLatencyHandleEvents(t *testing.T) {
+	timestamp := time.Now().Add(-time.Second)
+	cases := []struct {
+		name                string
+		closeWith           *metav1.Status
+		wantResourceVersion string
+		wantDropped         int
+		wantRelisted        int
+		wantRewatched       int
+	}{
+		{
+			name:                "closed without error",
+			wantResourceVersion: "3",
+			wantRewatched:       1,
+		},
+		{
+			name:                "error",
+			closeWith:           &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusInternalServerError, Reason: metav1.StatusReasonInternalError},
+			wantResourceVersion: "3",
+			wantDropped:         1,
+		},
+		{
+			name:                "expired",
+			closeWith:           &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired},
+			wantResourceVersion: "",
+			wantRelisted:        1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := &watchLatencyMeasurement{stopCh: make(chan struct{})}
+			resource := &watchedResource{}
+			watcher := watch.NewFakeWithChanSize(10, false)
+			watcher.Add(newWatchedObject("pod-1", "1", timestamp))
+			// The same timestamp is observed only once.
+			watcher.Modify(newWatchedObject("pod-1", "2", timestamp))
+			// Objects without timestamp are ignored.
+			watcher.Add(newWatchedObject("pod-2", "3", time.Time{}))
+			if c.closeWith != nil {
+				watcher.Error(c.closeWith)
+			}
+			watcher.Stop()
+
+			resourceVersion, stopped := w.handleEvents(watcher, resource, make(map[string]time.Time), "0")
+
+			assert.False(t, stopped)
+			assert.Equal(t, c.wantResourceVersion, resourceVersion)
+			assert.Len(t, resource.latencies, 1)
+			assert.Equal(t, c.wantDropped, resource.dropped)
+			assert.Equal(t, c.wantRelisted, resource.relisted)
+			assert.Equal(t, c.wantRewatched, resource.rewatched)
+		})
+	}
+}
+
+func TestWatchLatencyHandleEventsStopped(t *testing.T) {
+	w := &watchLatencyMeasurement{stopCh: make(chan struct{})}
+	close(w.stopCh)
+	resourceVersion, stopped := w.handleEvents(watch.NewFake(), &watchedResource{}, make(map[string]time.Time), "5")
+	assert.True(t, stopped)
+	assert.Equal(t, "5", resourceVersion)
+}
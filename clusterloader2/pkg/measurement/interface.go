@@ -19,8 +19,10 @@ package measurement
 import (
 	"time"
 
+	"k8s.io/perf-tests/clusterloader2/pkg/chaos"
 	"k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 )
 
 // MeasurementConfig provides client and parameters required for the measurement execution.
@@ -29,6 +31,8 @@ type MeasurementConfig struct {
 	ClusterFramework *framework.Framework
 	// PrometheusFramework returns prometheus framework.
 	PrometheusFramework *framework.Framework
+	// PrometheusClient sends requests to Prometheus, nil if Prometheus is disabled.
+	PrometheusClient prometheus.Client
 	// Params is a map of {name: value} pairs enabling for injection of arbitrary config
 	// into the Execute method.
 	Params map[string]interface{}
@@ -37,6 +41,8 @@ type MeasurementConfig struct {
 	// Identifier identifies this instance of measurement.
 	Identifier    string
 	CloudProvider string
+	// ChaosEvents records failures simulated by chaos monkey.
+	ChaosEvents *chaos.EventRecorder
 }
 
 // Measurement is an common interface for all measurements methods. It should be implemented by the user to
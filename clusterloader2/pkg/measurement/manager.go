@@ -19,8 +19,10 @@ package measurement
 import (
 	"sync"
 
+	"k8s.io/perf-tests/clusterloader2/pkg/chaos"
 	"k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 )
 
 // MeasurementManager manages all measurement executions.
@@ -28,7 +30,9 @@ type MeasurementManager struct {
 	clusterFramework    *framework.Framework
 	clusterLoaderConfig *config.ClusterLoaderConfig
 	prometheusFramework *framework.Framework
+	prometheusClient    prometheus.Client
 	templateProvider    *config.TemplateProvider
+	chaosEvents         *chaos.EventRecorder
 
 	lock sync.Mutex
 	// map from method type and identifier to measurement instance.
@@ -38,12 +42,14 @@ type MeasurementManager struct {
 
 // CreateMeasurementManager creates new instance of MeasurementManager.
 func CreateMeasurementManager(clusterFramework, prometheusFramework *framework.Framework,
-	templateProvider *config.TemplateProvider, config *config.ClusterLoaderConfig) *MeasurementManager {
+	templateProvider *config.TemplateProvider, config *config.ClusterLoaderConfig, chaosEvents *chaos.EventRecorder) *MeasurementManager {
 	return &MeasurementManager{
 		clusterFramework:    clusterFramework,
 		clusterLoaderConfig: config,
 		prometheusFramework: prometheusFramework,
+		prometheusClient:    prometheus.NewClient(config, prometheusFramework),
 		templateProvider:    templateProvider,
+		chaosEvents:         chaosEvents,
 		measurements:        make(map[string]map[string]Measurement),
 		summaries:           make([]Summary, 0),
 	}
@@ -58,10 +64,12 @@ func (mm *MeasurementManager) Execute(methodName string, identifier string, para
 	config := &MeasurementConfig{
 		ClusterFramework:    mm.clusterFramework,
 		PrometheusFramework: mm.prometheusFramework,
+		PrometheusClient:    mm.prometheusClient,
 		Params:              params,
 		TemplateProvider:    mm.templateProvider,
 		Identifier:          identifier,
 		CloudProvider:       mm.clusterLoaderConfig.ClusterConfig.Provider,
+		ChaosEvents:         mm.chaosEvents,
 	}
 	summaries, err := measurementInstance.Execute(config)
 	mm.summaries = append(mm.summaries, summaries...)
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatherers
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/informer"
+)
+
+const (
+	oomKilledReason     = "OOMKilled"
+	informerSyncTimeout = time.Minute
+)
+
+// ContainerFailure describes restarts and OOM kills of a single container.
+type ContainerFailure struct {
+	Name      string `json:"name"`
+	Restarts  int32  `json:"restarts"`
+	OOMKilled int    `json:"oomKilled"`
+}
+
+// containerFailureTracker watches tracked pods and records restarts
+// and OOM kills of their containers.
+type containerFailureTracker struct {
+	lock     sync.Mutex
+	failures map[string]*ContainerFailure
+	// trackedPods contains keys (namespace/name) of tracked pods. If nil, all watched pods are tracked.
+	trackedPods map[string]bool
+}
+
+func newContainerFailureTracker(trackedPods map[string]bool) *containerFailureTracker {
+	return &containerFailureTracker{
+		failures:    make(map[string]*ContainerFailure),
+		trackedPods: trackedPods,
+	}
+}
+
+// start starts watching pods in a given namespace matching labelSelector until stopCh is closed.
+func (t *containerFailureTracker) start(c clientset.Interface, namespace, labelSelector string, stopCh chan struct{}) error {
+	i := informer.NewInformer(c, "pods", namespace, "", labelSelector, t.handlePodUpdate)
+	return informer.StartAndSync(i, stopCh, informerSyncTimeout)
+}
+
+func (t *containerFailureTracker) handlePodUpdate(oldObj, newObj interface{}) {
+	// Only updates are interesting, initial state of pods is a baseline.
+	if oldObj == nil || newObj == nil {
+		return
+	}
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if t.trackedPods != nil && !t.trackedPods[podKey(newPod)] {
+		return
+	}
+	oldStatuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range append(oldPod.Status.InitContainerStatuses, oldPod.Status.ContainerStatuses...) {
+		oldStatuses[status.Name] = status
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, status := range append(newPod.Status.InitContainerStatuses, newPod.Status.ContainerStatuses...) {
+		oldStatus := oldStatuses[status.Name]
+		name := newPod.Name + "/" + status.Name
+		if status.RestartCount > oldStatus.RestartCount {
+			t.getFailure(name).Restarts += status.RestartCount - oldStatus.RestartCount
+			if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == oomKilledReason {
+				t.getFailure(name).OOMKilled++
+			}
+			continue
+		}
+		// Containers which are not restarted (e.g. with restartPolicy Never) stay terminated.
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason == oomKilledReason && oldStatus.State.Terminated == nil {
+			t.getFailure(name).OOMKilled++
+		}
+	}
+}
+
+// podKey returns the key identifying the pod among the tracked pods.
+func podKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+func (t *containerFailureTracker) getFailure(name string) *ContainerFailure {
+	if _, ok := t.failures[name]; !ok {
+		t.failures[name] = &ContainerFailure{Name: name}
+	}
+	return t.failures[name]
+}
+
+// get returns recorded container failures sorted by container name.
+func (t *containerFailureTracker) get() []ContainerFailure {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	result := make([]ContainerFailure, 0, len(t.failures))
+	for _, failure := range t.failures {
+		result = append(result, *failure)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gatherers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPod(name string, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{ContainerStatuses: statuses},
+	}
+}
+
+func terminatedState(reason string) corev1.ContainerState {
+	return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason}}
+}
+
+func TestContainerFailureTracker(t *testing.T) {
+	tracker := newContainerFailureTracker(nil)
+
+	// Initial state is ignored.
+	tracker.handlePodUpdate(nil, newPod("pod-a", corev1.ContainerStatus{Name: "c", RestartCount: 3}))
+	// Restart caused by OOM kill.
+	tracker.handlePodUpdate(
+		newPod("pod-a", corev1.ContainerStatus{Name: "c", RestartCount: 3}),
+		newPod("pod-a", corev1.ContainerStatus{Name: "c", RestartCount: 4, LastTerminationState: terminatedState(oomKilledReason)}),
+	)
+	// Restart caused by error.
+	tracker.handlePodUpdate(
+		newPod("pod-b", corev1.ContainerStatus{Name: "c"}),
+		newPod("pod-b", corev1.ContainerStatus{Name: "c", RestartCount: 2, LastTerminationState: terminatedState("Error")}),
+	)
+	// OOM kill of container which is not restarted.
+	tracker.handlePodUpdate(
+		newPod("pod-c", corev1.ContainerStatus{Name: "c"}),
+		newPod("pod-c", corev1.ContainerStatus{Name: "c", State: terminatedState(oomKilledReason)}),
+	)
+	// Status update of already terminated container.
+	tracker.handlePodUpdate(
+		newPod("pod-c", corev1.ContainerStatus{Name: "c", State: terminatedState(oomKilledReason)}),
+		newPod("pod-c", corev1.ContainerStatus{Name: "c", State: terminatedState(oomKilledReason)}),
+	)
+
+	assert.Equal(t, []ContainerFailure{
+		{Name: "pod-a/c", Restarts: 1, OOMKilled: 1},
+		{Name: "pod-b/c", Restarts: 2},
+		{Name: "pod-c/c", OOMKilled: 1},
+	}, tracker.get())
+}
+
+func TestContainerFailureTrackerTrackedPods(t *testing.T) {
+	tracker := newContainerFailureTracker(map[string]bool{"default/pod-a": true})
+	for _, key := range []struct{ namespace, name string }{{"default", "pod-a"}, {"default", "pod-b"}, {"other", "pod-a"}} {
+		before := newPod(key.name, corev1.ContainerStatus{Name: "c"})
+		after := newPod(key.name, corev1.ContainerStatus{Name: "c", RestartCount: 1})
+		before.Namespace, after.Namespace = key.namespace, key.namespace
+		tracker.handlePodUpdate(before, after)
+	}
+	assert.Equal(t, []ContainerFailure{{Name: "pod-a/c", Restarts: 1}}, tracker.get())
+}
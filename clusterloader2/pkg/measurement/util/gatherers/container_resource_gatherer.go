@@ -29,6 +29,7 @@ import (
 	"k8s.io/klog"
 	"k8s.io/kubernetes/pkg/util/system"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 )
 
 // NodesSet is a flag defining the node set range.
@@ -41,6 +42,8 @@ const (
 	MasterNodes NodesSet = 1
 	// MasterAndDNSNodes - all containers on Master nodes and DNS containers on other nodes
 	MasterAndDNSNodes NodesSet = 2
+	// PodNodes - containers of the tracked pods on nodes running them
+	PodNodes NodesSet = 3
 )
 
 // ResourceUsageSummary represents summary of resource usage per container.
@@ -51,28 +54,50 @@ func (r *ResourceUsageSummary) Get(perc string) []util.SingleContainerSummary {
 	return (*r)[perc]
 }
 
+// NodeResourceUsageSummary represents summary of resource usage per node.
+type NodeResourceUsageSummary map[string][]util.SingleNodeSummary
+
+// Get returns collection of SingleNodeSummaries for given percentile.
+func (r *NodeResourceUsageSummary) Get(perc string) []util.SingleNodeSummary {
+	return (*r)[perc]
+}
+
+// ContainerHealthSummary represents memory high-water marks and failures of containers.
+type ContainerHealthSummary struct {
+	MemoryHighWaterMarks []util.ContainerMemoryHighWaterMark `json:"memoryHighWaterMarks"`
+	Failures             []ContainerFailure                  `json:"failures"`
+}
+
 // ContainerResourceGatherer gathers resource metrics from containers.
 type ContainerResourceGatherer struct {
-	client       clientset.Interface
-	isRunning    bool
-	stopCh       chan struct{}
-	workers      []resourceGatherWorker
-	workerWg     sync.WaitGroup
-	containerIDs []string
-	options      ResourceGathererOptions
+	client         clientset.Interface
+	isRunning      bool
+	stopCh         chan struct{}
+	workers        []resourceGatherWorker
+	workerWg       sync.WaitGroup
+	containerIDs   []string
+	options        ResourceGathererOptions
+	failureTracker *containerFailureTracker
 }
 
 // ResourceGathererOptions specifies options for ContainerResourceGatherer.
 type ResourceGathererOptions struct {
-	InKubemark                  bool
-	Nodes                       NodesSet
+	InKubemark bool
+	Nodes      NodesSet
+	// Namespace and LabelSelector select the tracked pods if no PodList is passed in.
+	// If LabelSelector is set, containers are tracked only in the selected pods
+	// (rather than all containers having the same names).
+	Namespace     string
+	LabelSelector string
+	// CollectNodeUsage enables collecting node-level cpu, memory, disk and network usage.
+	CollectNodeUsage            bool
 	ResourceDataGatheringPeriod time.Duration
 	ProbeDuration               time.Duration
 	PrintVerboseLogs            bool
 }
 
 // NewResourceUsageGatherer creates new instance of ContainerResourceGatherer
-func NewResourceUsageGatherer(c clientset.Interface, host, provider string, options ResourceGathererOptions, pods *corev1.PodList) (*ContainerResourceGatherer, error) {
+func NewResourceUsageGatherer(c clientset.Interface, masterAccess masteraccess.MasterAccess, options ResourceGathererOptions, pods *corev1.PodList) (*ContainerResourceGatherer, error) {
 	g := ContainerResourceGatherer{
 		client:       c,
 		isRunning:    true,
@@ -91,19 +116,30 @@ func NewResourceUsageGatherer(c clientset.Interface, host, provider string, opti
 			resourceDataGatheringPeriod: options.ResourceDataGatheringPeriod,
 			probeDuration:               options.ProbeDuration,
 			printVerboseLogs:            options.PrintVerboseLogs,
-			host:                        host,
-			provider:                    provider,
+			masterAccess:                masterAccess,
 		})
 	} else {
-		// Tracks kube-system pods if no valid PodList is passed in.
+		// Tracks kube-system pods (or pods selected by options) if no valid PodList is passed in.
 		var err error
+		podsPassed := pods != nil
 		if pods == nil {
-			pods, err = c.CoreV1().Pods("kube-system").List(metav1.ListOptions{})
+			namespace := options.Namespace
+			if namespace == "" {
+				namespace = metav1.NamespaceSystem
+			}
+			pods, err = c.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: options.LabelSelector})
 			if err != nil {
 				return nil, fmt.Errorf("listing pods error: %v", err)
 			}
 		}
+		containerID := func(pod *corev1.Pod, containerName string) string {
+			if options.LabelSelector != "" {
+				return pod.Name + "/" + containerName
+			}
+			return containerName
+		}
 		dnsNodes := make(map[string]bool)
+		podNodes := make(map[string]bool)
 		for _, pod := range pods.Items {
 			if (options.Nodes == MasterNodes) && !system.IsMasterNode(pod.Spec.NodeName) {
 				continue
@@ -112,14 +148,15 @@ func NewResourceUsageGatherer(c clientset.Interface, host, provider string, opti
 				continue
 			}
 			for _, container := range pod.Status.InitContainerStatuses {
-				g.containerIDs = append(g.containerIDs, container.Name)
+				g.containerIDs = append(g.containerIDs, containerID(&pod, container.Name))
 			}
 			for _, container := range pod.Status.ContainerStatuses {
-				g.containerIDs = append(g.containerIDs, container.Name)
+				g.containerIDs = append(g.containerIDs, containerID(&pod, container.Name))
 			}
 			if options.Nodes == MasterAndDNSNodes {
 				dnsNodes[pod.Spec.NodeName] = true
 			}
+			podNodes[pod.Spec.NodeName] = true
 		}
 		nodeList, err := c.CoreV1().Nodes().List(metav1.ListOptions{})
 		if err != nil {
@@ -127,7 +164,10 @@ func NewResourceUsageGatherer(c clientset.Interface, host, provider string, opti
 		}
 
 		for _, node := range nodeList.Items {
-			if options.Nodes == AllNodes || system.IsMasterNode(node.Name) || dnsNodes[node.Name] {
+			if options.Nodes == PodNodes && !podNodes[node.Name] {
+				continue
+			}
+			if options.Nodes == AllNodes || options.Nodes == PodNodes || system.IsMasterNode(node.Name) || dnsNodes[node.Name] {
 				g.workerWg.Add(1)
 				g.workers = append(g.workers, resourceGatherWorker{
 					c:                           c,
@@ -140,12 +180,18 @@ func NewResourceUsageGatherer(c clientset.Interface, host, provider string, opti
 					resourceDataGatheringPeriod: options.ResourceDataGatheringPeriod,
 					probeDuration:               options.ProbeDuration,
 					printVerboseLogs:            options.PrintVerboseLogs,
+					collectNodeUsage:            options.CollectNodeUsage,
 				})
 				if options.Nodes == MasterNodes {
 					break
 				}
 			}
 		}
+		// Failure tracker is started last, so that it doesn't leak if any of the above fails.
+		if err := g.startFailureTracker(pods, podsPassed); err != nil {
+			g.stop()
+			return nil, err
+		}
 	}
 	return &g, nil
 }
@@ -220,6 +266,95 @@ func (g *ContainerResourceGatherer) StopAndSummarize(percentiles []int) (*Resour
 	return &summary, nil
 }
 
+// startFailureTracker starts watching restarts and OOM kills of the tracked pods' containers.
+func (g *ContainerResourceGatherer) startFailureTracker(pods *corev1.PodList, podsPassed bool) error {
+	namespace := g.options.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceSystem
+	}
+	var trackedPods map[string]bool
+	if podsPassed {
+		// Passed pods can be in any namespace, only they are tracked.
+		namespace = metav1.NamespaceAll
+		trackedPods = make(map[string]bool)
+		for i := range pods.Items {
+			trackedPods[podKey(&pods.Items[i])] = true
+		}
+	}
+	g.failureTracker = newContainerFailureTracker(trackedPods)
+	if err := g.failureTracker.start(g.client, namespace, g.options.LabelSelector, g.stopCh); err != nil {
+		return fmt.Errorf("starting container failure tracker error: %v", err)
+	}
+	return nil
+}
+
+// SummarizeContainerHealth returns memory high-water marks and failures (restarts and OOM kills)
+// of the tracked containers. It should be called after StopAndSummarize.
+func (g *ContainerResourceGatherer) SummarizeContainerHealth() *ContainerHealthSummary {
+	highWaterMarks := make(map[string]*util.ContainerMemoryHighWaterMark)
+	for i := range g.workers {
+		if !g.workers[i].finished {
+			continue
+		}
+		for name, mark := range g.workers[i].memoryHighWaterMarks {
+			if current, ok := highWaterMarks[name]; ok {
+				current.Update(mark.MaxWorkingSetInBytes, mark.MaxRSSInBytes)
+			} else {
+				markCopy := *mark
+				highWaterMarks[name] = &markCopy
+			}
+		}
+	}
+	summary := &ContainerHealthSummary{
+		MemoryHighWaterMarks: make([]util.ContainerMemoryHighWaterMark, 0, len(highWaterMarks)),
+		Failures:             []ContainerFailure{},
+	}
+	for _, mark := range highWaterMarks {
+		summary.MemoryHighWaterMarks = append(summary.MemoryHighWaterMarks, *mark)
+	}
+	sort.Slice(summary.MemoryHighWaterMarks, func(i, j int) bool {
+		return summary.MemoryHighWaterMarks[i].Name < summary.MemoryHighWaterMarks[j].Name
+	})
+	if g.failureTracker != nil {
+		summary.Failures = g.failureTracker.get()
+	}
+	return summary
+}
+
+// SummarizeNodeUsage processes the collected node stats and generates node resource usage
+// summary for the passed-in percentiles. It should be called after StopAndSummarize.
+func (g *ContainerResourceGatherer) SummarizeNodeUsage(percentiles []int) *NodeResourceUsageSummary {
+	var dataSeries []util.ResourceUsagePerNode
+	for i := range g.workers {
+		if g.workers[i].finished {
+			dataSeries = append(dataSeries, g.workers[i].nodeDataSeries...)
+		}
+	}
+	data := util.ComputeNodePercentiles(dataSeries, percentiles)
+
+	summary := make(NodeResourceUsageSummary)
+	for _, perc := range percentiles {
+		sortedKeys := []string{}
+		for name := range data[perc] {
+			sortedKeys = append(sortedKeys, name)
+		}
+		sort.Strings(sortedKeys)
+		for _, name := range sortedKeys {
+			usage := data[perc][name]
+			summary[strconv.Itoa(perc)] = append(summary[strconv.Itoa(perc)], util.SingleNodeSummary{
+				Name:      name,
+				Cpu:       usage.CPUUsageInCores,
+				Mem:       usage.MemoryWorkingSetInBytes,
+				DiskRead:  usage.DiskReadBytesPerSecond,
+				DiskWrite: usage.DiskWriteBytesPerSecond,
+				NetworkRx: usage.NetworkRxBytesPerSecond,
+				NetworkTx: usage.NetworkTxBytesPerSecond,
+			})
+		}
+	}
+	return &summary
+}
+
 // Dispose disposes container resource gatherer.
 func (g *ContainerResourceGatherer) Dispose() {
 	g.stop()
@@ -26,6 +26,7 @@ import (
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/kubelet"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/kubemark"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 )
 
 type resourceGatherWorker struct {
@@ -40,14 +41,17 @@ type resourceGatherWorker struct {
 	resourceDataGatheringPeriod time.Duration
 	probeDuration               time.Duration
 	printVerboseLogs            bool
-	host                        string
-	provider                    string
+	masterAccess                masteraccess.MasterAccess
+	collectNodeUsage            bool
+	lastNodeStats               *kubelet.NodeResourceStats
+	nodeDataSeries              []util.ResourceUsagePerNode
+	memoryHighWaterMarks        map[string]*util.ContainerMemoryHighWaterMark
 }
 
 func (w *resourceGatherWorker) singleProbe() {
 	data := make(util.ResourceUsagePerContainer)
 	if w.inKubemark {
-		kubemarkData := kubemark.GetKubemarkMasterComponentsResourceUsage(w.host, w.provider)
+		kubemarkData := kubemark.GetKubemarkMasterComponentsResourceUsage(w.masterAccess)
 		if data == nil {
 			return
 		}
@@ -70,10 +74,67 @@ func (w *resourceGatherWorker) singleProbe() {
 				klog.Infof("Get container %v usage on node %v. CPUUsageInCores: %v, MemoryUsageInBytes: %v, MemoryWorkingSetInBytes: %v", k, w.nodeName, v.CPUUsageInCores, v.MemoryUsageInBytes, v.MemoryWorkingSetInBytes)
 			}
 		}
+		if w.collectNodeUsage {
+			w.probeNode()
+		}
 	}
+	w.updateMemoryHighWaterMarks(data)
 	w.dataSeries = append(w.dataSeries, data)
 }
 
+// updateMemoryHighWaterMarks records maximum working set and RSS observed for every container.
+func (w *resourceGatherWorker) updateMemoryHighWaterMarks(data util.ResourceUsagePerContainer) {
+	if w.memoryHighWaterMarks == nil {
+		w.memoryHighWaterMarks = make(map[string]*util.ContainerMemoryHighWaterMark)
+	}
+	for name, usage := range data {
+		if _, ok := w.memoryHighWaterMarks[name]; !ok {
+			w.memoryHighWaterMarks[name] = &util.ContainerMemoryHighWaterMark{Name: name}
+		}
+		w.memoryHighWaterMarks[name].Update(usage.MemoryWorkingSetInBytes, usage.MemoryRSSInBytes)
+	}
+}
+
+// probeNode reads node stats and computes disk and network throughput
+// since the previous probe. The first probe only records the counters.
+func (w *resourceGatherWorker) probeNode() {
+	stats, err := kubelet.GetNodeResourceStats(w.c, w.nodeName)
+	if err != nil {
+		klog.Errorf("error while reading node stats from %v: %v", w.nodeName, err)
+		return
+	}
+	last := w.lastNodeStats
+	w.lastNodeStats = stats
+	if last == nil {
+		return
+	}
+	interval := stats.Timestamp.Sub(last.Timestamp).Seconds()
+	if interval <= 0 {
+		return
+	}
+	rate := func(current, previous uint64) float64 {
+		// Counters may be reset, e.g. after kubelet restart.
+		if current < previous {
+			return 0
+		}
+		return float64(current-previous) / interval
+	}
+	usage := &util.NodeResourceUsage{
+		Name:                    w.nodeName,
+		Timestamp:               stats.Timestamp,
+		CPUUsageInCores:         stats.CPUUsageInCores,
+		MemoryWorkingSetInBytes: stats.MemoryWorkingSetInBytes,
+		DiskReadBytesPerSecond:  rate(stats.DiskReadBytes, last.DiskReadBytes),
+		DiskWriteBytesPerSecond: rate(stats.DiskWriteBytes, last.DiskWriteBytes),
+		NetworkRxBytesPerSecond: rate(stats.NetworkRxBytes, last.NetworkRxBytes),
+		NetworkTxBytesPerSecond: rate(stats.NetworkTxBytes, last.NetworkTxBytes),
+	}
+	if w.printVerboseLogs {
+		klog.Infof("Get node %v usage: %+v", w.nodeName, *usage)
+	}
+	w.nodeDataSeries = append(w.nodeDataSeries, util.ResourceUsagePerNode{w.nodeName: usage})
+}
+
 func (w *resourceGatherWorker) gather(initialSleep time.Duration) {
 	defer utilruntime.HandleCrash()
 	defer w.wg.Done()
@@ -17,7 +17,9 @@ limitations under the License.
 package util
 
 import (
+	"math"
 	"reflect"
+	"sort"
 
 	"github.com/prometheus/common/model"
 )
@@ -60,3 +62,63 @@ func ConvertSampleToBucket(sample *model.Sample, h *HistogramVec) {
 	}
 	hist.Buckets[string(sample.Metric["le"])] = int(sample.Value)
 }
+
+// BucketCounts represents cumulative histogram buckets,
+// i.e. a map from bucket upper bound to number of observations lower or equal to it.
+type BucketCounts map[float64]float64
+
+// Add adds observations from the other histogram.
+func (b BucketCounts) Add(other BucketCounts) {
+	for le, count := range other {
+		b[le] += count
+	}
+}
+
+// Subtract returns histogram of observations that are present in b, but not in other.
+// It can be used for computing the delta between two scrapes of the same counter histogram.
+func (b BucketCounts) Subtract(other BucketCounts) BucketCounts {
+	result := make(BucketCounts, len(b))
+	for le, count := range b {
+		result[le] = count - other[le]
+	}
+	return result
+}
+
+// Count returns total number of observations.
+func (b BucketCounts) Count() float64 {
+	return b[math.Inf(1)]
+}
+
+// Quantile estimates q-quantile (0 <= q <= 1) of the observations
+// using linear interpolation within a bucket, the same way as
+// Prometheus' histogram_quantile does. It returns 0 for an empty histogram.
+func (b BucketCounts) Quantile(q float64) float64 {
+	bounds := make([]float64, 0, len(b))
+	for le := range b {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) == 0 || !math.IsInf(bounds[len(bounds)-1], 1) {
+		return 0
+	}
+	total := b[bounds[len(bounds)-1]]
+	if total <= 0 {
+		return 0
+	}
+	rank := q * total
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, le := range bounds {
+		count := b[le]
+		if count >= rank {
+			if math.IsInf(le, 1) {
+				return lowerBound
+			}
+			if count == lowerCount {
+				return le
+			}
+			return lowerBound + (le-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lowerBound, lowerCount = le, count
+	}
+	return lowerBound
+}
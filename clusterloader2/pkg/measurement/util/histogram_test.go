@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketCountsQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	cases := []struct {
+		name    string
+		buckets BucketCounts
+		q       float64
+		want    float64
+	}{{
+		name:    "empty",
+		buckets: BucketCounts{},
+		q:       0.5,
+		want:    0,
+	}, {
+		name:    "no observations",
+		buckets: BucketCounts{1: 0, inf: 0},
+		q:       0.99,
+		want:    0,
+	}, {
+		name:    "interpolation in first bucket",
+		buckets: BucketCounts{1: 10, 2: 10, inf: 10},
+		q:       0.5,
+		want:    0.5,
+	}, {
+		name:    "interpolation in middle bucket",
+		buckets: BucketCounts{1: 50, 2: 100, inf: 100},
+		q:       0.75,
+		want:    1.5,
+	}, {
+		name:    "quantile in infinite bucket",
+		buckets: BucketCounts{1: 50, 2: 90, inf: 100},
+		q:       0.99,
+		want:    2,
+	}}
+
+	for _, c := range cases {
+		assert.InDelta(t, c.want, c.buckets.Quantile(c.q), 1e-9, c.name)
+	}
+}
+
+func TestBucketCountsSubtract(t *testing.T) {
+	inf := math.Inf(1)
+	start := BucketCounts{1: 5, 2: 10, inf: 10}
+	end := BucketCounts{1: 15, 2: 30, inf: 40}
+	delta := end.Subtract(start)
+	assert.Equal(t, BucketCounts{1: 10, 2: 20, inf: 30}, delta)
+	assert.Equal(t, float64(30), delta.Count())
+}
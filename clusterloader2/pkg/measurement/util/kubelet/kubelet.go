@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/common/model"
 	clientset "k8s.io/client-go/kubernetes"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
 	"k8s.io/kubernetes/pkg/master/ports"
@@ -48,7 +49,7 @@ const (
 // the stats points in ContainerResourceUsage.CPUInterval.
 //
 // containerNames is a function returning a collection of container names in which
-// user is interested in.
+// user is interested in. Container can be also identified by <pod name>/<container name>.
 func GetOneTimeResourceUsageOnNode(
 	c clientset.Interface,
 	nodeName string,
@@ -94,7 +95,7 @@ func GetOneTimeResourceUsageOnNode(
 		for _, container := range pod.Containers {
 			isInteresting := false
 			for _, interestingContainerName := range containers {
-				if container.Name == interestingContainerName {
+				if container.Name == interestingContainerName || pod.PodRef.Name+"/"+container.Name == interestingContainerName {
 					isInteresting = true
 					observedContainers = append(observedContainers, container.Name)
 					break
@@ -111,6 +112,66 @@ func GetOneTimeResourceUsageOnNode(
 	return usageMap, nil
 }
 
+// NodeResourceStats represents resource stats of a node.
+// Disk and network stats are cumulative counters.
+type NodeResourceStats struct {
+	Timestamp               time.Time
+	CPUUsageInCores         float64
+	MemoryWorkingSetInBytes uint64
+	DiskReadBytes           uint64
+	DiskWriteBytes          uint64
+	NetworkRxBytes          uint64
+	NetworkTxBytes          uint64
+}
+
+// GetNodeResourceStats returns node-level resource stats.
+// Cpu, memory and network stats are read from the node's /stats/summary endpoint,
+// disk stats are read from cAdvisor metrics of the root cgroup.
+func GetNodeResourceStats(c clientset.Interface, nodeName string) (*NodeResourceStats, error) {
+	summary, err := getStatsSummary(c, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	result := &NodeResourceStats{Timestamp: time.Now()}
+	if cpu := summary.Node.CPU; cpu != nil {
+		result.CPUUsageInCores = float64(removeUint64Ptr(cpu.UsageNanoCores)) / 1000000000
+	}
+	if memory := summary.Node.Memory; memory != nil {
+		result.MemoryWorkingSetInBytes = removeUint64Ptr(memory.WorkingSetBytes)
+	}
+	if network := summary.Node.Network; network != nil {
+		if len(network.Interfaces) == 0 {
+			result.NetworkRxBytes = removeUint64Ptr(network.RxBytes)
+			result.NetworkTxBytes = removeUint64Ptr(network.TxBytes)
+		}
+		for _, iface := range network.Interfaces {
+			result.NetworkRxBytes += removeUint64Ptr(iface.RxBytes)
+			result.NetworkTxBytes += removeUint64Ptr(iface.TxBytes)
+		}
+	}
+
+	data, err := getCadvisorMetrics(c, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := util.ExtractMetricSamples(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, sample := range samples {
+		if sample.Metric["id"] != "/" {
+			continue
+		}
+		switch sample.Metric[model.MetricNameLabel] {
+		case "container_fs_reads_bytes_total":
+			result.DiskReadBytes += uint64(sample.Value)
+		case "container_fs_writes_bytes_total":
+			result.DiskWriteBytes += uint64(sample.Value)
+		}
+	}
+	return result, nil
+}
+
 // getStatsSummary contacts kubelet for the container information.
 func getStatsSummary(c clientset.Interface, nodeName string) (*stats.Summary, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), singleCallTimeout)
@@ -136,6 +197,44 @@ func getStatsSummary(c clientset.Interface, nodeName string) (*stats.Summary, er
 	return &summary, nil
 }
 
+// GetMetrics contacts kubelet for its metrics in the prometheus text format.
+func GetMetrics(c clientset.Interface, nodeName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), singleCallTimeout)
+	defer cancel()
+
+	data, err := c.CoreV1().RESTClient().Get().
+		Context(ctx).
+		Resource("nodes").
+		SubResource("proxy").
+		Name(fmt.Sprintf("%v:%v", nodeName, ports.KubeletPort)).
+		Suffix("metrics").
+		Do().Raw()
+
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// getCadvisorMetrics contacts kubelet for cAdvisor metrics in the prometheus text format.
+func getCadvisorMetrics(c clientset.Interface, nodeName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), singleCallTimeout)
+	defer cancel()
+
+	data, err := c.CoreV1().RESTClient().Get().
+		Context(ctx).
+		Resource("nodes").
+		SubResource("proxy").
+		Name(fmt.Sprintf("%v:%v", nodeName, ports.KubeletPort)).
+		Suffix("metrics/cadvisor").
+		Do().Raw()
+
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func removeUint64Ptr(ptr *uint64) uint64 {
 	if ptr == nil {
 		return 0
@@ -22,7 +22,7 @@ import (
 	"strings"
 
 	"k8s.io/klog"
-	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/masteraccess"
 )
 
 // KubemarkResourceUsage represents resources used by the kubemark.
@@ -32,22 +32,19 @@ type KubemarkResourceUsage struct {
 	CPUUsageInCores         float64
 }
 
-func getMasterUsageByPrefix(host, provider, prefix string) (string, error) {
-	sshResult, err := util.SSH(fmt.Sprintf("ps ax -o %%cpu,rss,command | tail -n +2 | grep %v | sed 's/\\s+/ /g'", prefix), host+":22", provider)
-	if err != nil {
-		return "", err
-	}
-	return sshResult.Stdout, nil
+func getMasterUsageByPrefix(access masteraccess.MasterAccess, prefix string) (string, error) {
+	// Kubemark master components are not run in pods, so the command is run on the master itself.
+	return access.RunCommand("", fmt.Sprintf("ps ax -o %%cpu,rss,command | tail -n +2 | grep %v | sed 's/\\s+/ /g'", prefix))
 }
 
 // GetKubemarkMasterComponentsResourceUsage returns resource usage of the kubemark components.
 // TODO: figure out how to move this to kubemark directory (need to factor test SSH out of e2e framework)
-func GetKubemarkMasterComponentsResourceUsage(host, provider string) map[string]*KubemarkResourceUsage {
+func GetKubemarkMasterComponentsResourceUsage(access masteraccess.MasterAccess) map[string]*KubemarkResourceUsage {
 	result := make(map[string]*KubemarkResourceUsage)
 	// Get kubernetes component resource usage
-	sshResult, err := getMasterUsageByPrefix(host, provider, "kube")
+	sshResult, err := getMasterUsageByPrefix(access, "kube")
 	if err != nil {
-		klog.Errorf("error when trying to SSH to master machine. Skipping probe. %v", err)
+		klog.Errorf("error when trying to access master machine. Skipping probe. %v", err)
 		return nil
 	}
 	scanner := bufio.NewScanner(strings.NewReader(sshResult))
@@ -63,9 +60,9 @@ func GetKubemarkMasterComponentsResourceUsage(host, provider string) map[string]
 		}
 	}
 	// Get etcd resource usage
-	sshResult, err = getMasterUsageByPrefix(host, provider, "bin/etcd")
+	sshResult, err = getMasterUsageByPrefix(access, "bin/etcd")
 	if err != nil {
-		klog.Errorf("error when trying to SSH to master machine. Skipping probe")
+		klog.Errorf("error when trying to access master machine. Skipping probe")
 		return nil
 	}
 	scanner = bufio.NewScanner(strings.NewReader(sshResult))
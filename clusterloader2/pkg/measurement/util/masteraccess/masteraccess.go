@@ -0,0 +1,289 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package masteraccess
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	utilexec "k8s.io/client-go/util/exec"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+const (
+	// SSH accesses the master over ssh.
+	SSH = "ssh"
+	// Proxy accesses the master components through the api server pod proxy.
+	Proxy = "proxy"
+	// Exec accesses the master components by executing commands in their static pods.
+	Exec = "exec"
+	// HTTP accesses the master components directly over http.
+	HTTP = "http"
+
+	singleRequestTimeout = 5 * time.Minute
+
+	// commandNotFoundCode is the exit code of the shell if command is not found.
+	commandNotFoundCode = 127
+)
+
+// MasterAccess provides access to the components running on the master.
+type MasterAccess interface {
+	// Request sends http request with a given method to the given port and path
+	// of the component running on the master.
+	Request(component, method string, port int, path string) (string, error)
+	// RunCommand executes shell command on the master.
+	// Access methods, which execute commands inside of the component's pod, use the given component.
+	RunCommand(component, cmd string) (string, error)
+	// String returns the name of the access method.
+	String() string
+}
+
+// Config describes how the master should be accessed.
+type Config struct {
+	// Method is the access method (ssh, proxy, exec or http).
+	// If empty, method is chosen based on the provider.
+	Method     string
+	Provider   string
+	Host       string
+	MasterName string
+	Client     clientset.Interface
+	// RestConfig is the config of the Client, it is required by the exec method.
+	RestConfig *restclient.Config
+}
+
+// GetMethod returns the access method used for the config.
+func (c *Config) GetMethod() string {
+	if c.Method == "" {
+		return getDefaultMethod(c.Provider)
+	}
+	return c.Method
+}
+
+// New creates MasterAccess for the given config.
+func New(config *Config) (MasterAccess, error) {
+	method := config.GetMethod()
+	switch method {
+	case SSH:
+		return &sshAccess{host: config.Host, provider: config.Provider}, nil
+	case Proxy:
+		return &proxyAccess{client: config.Client, masterName: config.MasterName}, nil
+	case Exec:
+		if config.RestConfig == nil {
+			return nil, fmt.Errorf("rest config is required by %s master access", method)
+		}
+		return &execAccess{
+			client:      config.Client,
+			restConfig:  config.RestConfig,
+			masterName:  config.MasterName,
+			unsupported: make(map[string]error),
+		}, nil
+	case HTTP:
+		return &httpAccess{host: config.Host, client: &http.Client{Timeout: singleRequestTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown master access method %q", method)
+	}
+}
+
+// IsValidMethod checks whether given master access method is supported.
+// Empty method (meaning default one) is valid.
+func IsValidMethod(method string) bool {
+	switch method {
+	case "", SSH, Proxy, Exec, HTTP:
+		return true
+	}
+	return false
+}
+
+func getDefaultMethod(provider string) string {
+	switch provider {
+	case "kind":
+		return Exec
+	default:
+		return SSH
+	}
+}
+
+func curlCommand(method string, port int, path string) string {
+	return fmt.Sprintf("curl -s -f -X %s http://localhost:%d%s", strings.ToUpper(method), port, path)
+}
+
+type sshAccess struct {
+	host     string
+	provider string
+}
+
+func (s *sshAccess) Request(_, method string, port int, path string) (string, error) {
+	return s.RunCommand("", curlCommand(method, port, path))
+}
+
+func (s *sshAccess) RunCommand(_, cmd string) (string, error) {
+	sshResult, err := util.SSH(cmd, s.host+":22", s.provider)
+	if err != nil || sshResult.Code != 0 {
+		return "", fmt.Errorf("unexpected error (code: %d) in ssh connection to master: %#v", sshResult.Code, err)
+	}
+	return sshResult.Stdout, nil
+}
+
+func (s *sshAccess) String() string {
+	return SSH
+}
+
+type proxyAccess struct {
+	client     clientset.Interface
+	masterName string
+}
+
+func (p *proxyAccess) Request(component, method string, port int, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), singleRequestTimeout)
+	defer cancel()
+	body, err := p.client.CoreV1().RESTClient().Verb(strings.ToUpper(method)).
+		Context(ctx).
+		Namespace(metav1.NamespaceSystem).
+		Resource("pods").
+		Name(fmt.Sprintf("%v:%v", getStaticPodName(component, p.masterName), port)).
+		SubResource("proxy").
+		Suffix(path).
+		Do().Raw()
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (p *proxyAccess) RunCommand(_, _ string) (string, error) {
+	return "", fmt.Errorf("running commands is not supported by %s master access", p)
+}
+
+func (p *proxyAccess) String() string {
+	return Proxy
+}
+
+// execAccess runs commands with /bin/sh inside of the component's pod and sends requests with curl.
+// Images of some components (e.g. distroless ones) contain neither of them,
+// the access fails for such components.
+type execAccess struct {
+	client     clientset.Interface
+	restConfig *restclient.Config
+	masterName string
+
+	lock sync.Mutex
+	// unsupported maps components lacking shell or curl to the error, so that
+	// subsequent calls fail without executing the command.
+	unsupported map[string]error
+}
+
+func (e *execAccess) Request(component, method string, port int, path string) (string, error) {
+	return e.RunCommand(component, curlCommand(method, port, path))
+}
+
+func (e *execAccess) RunCommand(component, cmd string) (string, error) {
+	if component == "" {
+		return "", fmt.Errorf("component has to be specified for %s master access", e)
+	}
+	e.lock.Lock()
+	err := e.unsupported[component]
+	e.lock.Unlock()
+	if err != nil {
+		return "", err
+	}
+	podName := getStaticPodName(component, e.masterName)
+	var stdout, stderr bytes.Buffer
+	command := []string{"/bin/sh", "-c", cmd}
+	if err := client.ExecInPod(e.client, e.restConfig, metav1.NamespaceSystem, podName, "", command, &stdout, &stderr, nil); err != nil {
+		if unsupportedErr := checkCommandNotFound(component, cmd, err, stderr.String()); unsupportedErr != nil {
+			e.lock.Lock()
+			e.unsupported[component] = unsupportedErr
+			e.lock.Unlock()
+			return "", unsupportedErr
+		}
+		return "", fmt.Errorf("executing command in %s pod error: %v, stderr: %s", component, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func (e *execAccess) String() string {
+	return Exec
+}
+
+type httpAccess struct {
+	host   string
+	client *http.Client
+}
+
+func (h *httpAccess) Request(_, method string, port int, path string) (string, error) {
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(h.host, strconv.Itoa(port)), path)
+	request, err := http.NewRequest(strings.ToUpper(method), url, nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := h.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response from %s error: %v", url, err)
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("unexpected response from %s: %s", url, response.Status)
+	}
+	return string(body), nil
+}
+
+func (h *httpAccess) RunCommand(_, _ string) (string, error) {
+	return "", fmt.Errorf("running commands is not supported by %s master access", h)
+}
+
+func (h *httpAccess) String() string {
+	return HTTP
+}
+
+// checkCommandNotFound returns descriptive error if exec failed because the component's container
+// has no shell or the executed command (e.g. curl), nil otherwise.
+func checkCommandNotFound(component, cmd string, err error, stderr string) error {
+	if exitErr, ok := err.(utilexec.ExitError); ok {
+		if exitErr.ExitStatus() == commandNotFoundCode {
+			command := strings.Fields(cmd)[0]
+			return fmt.Errorf("%s container has no %s (stderr: %s), use another master access method", component, command, stderr)
+		}
+		return nil
+	}
+	// Starting non-existing executable fails before the command is run, so there is no exit code.
+	if msg := err.Error(); strings.Contains(msg, "/bin/sh") &&
+		(strings.Contains(msg, "no such file or directory") || strings.Contains(msg, "executable file not found")) {
+		return fmt.Errorf("%s container has no shell (%v), use another master access method", component, err)
+	}
+	return nil
+}
+
+// getStaticPodName returns the name of the mirror pod of the static pod running on the master.
+func getStaticPodName(component, masterName string) string {
+	return fmt.Sprintf("%v-%v", component, masterName)
+}
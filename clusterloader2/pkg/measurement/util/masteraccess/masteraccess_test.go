@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package masteraccess
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	restclient "k8s.io/client-go/rest"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func newTestHTTPAccess(t *testing.T, handler http.HandlerFunc) (MasterAccess, int, func()) {
+	server := httptest.NewServer(handler)
+	host, portString, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("splitting server address error: %v", err)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		t.Fatalf("parsing server port error: %v", err)
+	}
+	access, err := New(&Config{Method: HTTP, Host: host})
+	if err != nil {
+		t.Fatalf("creating master access error: %v", err)
+	}
+	return access, port, server.Close
+}
+
+func TestHTTPAccessRequest(t *testing.T) {
+	access, port, closeServer := newTestHTTPAccess(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "%s metrics", r.Method)
+	})
+	defer closeServer()
+
+	body, err := access.Request("etcd", "get", port, "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, "GET metrics", body)
+
+	body, err = access.Request("kube-scheduler", "DELETE", port, "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE metrics", body)
+
+	_, err = access.Request("etcd", "GET", port, "/unknown")
+	assert.Error(t, err)
+}
+
+func TestHTTPAccessRunCommand(t *testing.T) {
+	access, _, closeServer := newTestHTTPAccess(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer closeServer()
+
+	_, err := access.RunCommand("kube-apiserver", "ps ax")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{config: Config{Provider: "gce"}, want: SSH},
+		{config: Config{Provider: "kubemark"}, want: SSH},
+		{config: Config{Provider: "gke", Method: Proxy}, want: Proxy},
+		{config: Config{Provider: "kind", RestConfig: &restclient.Config{}}, want: Exec},
+		{config: Config{Provider: "kind"}, wantErr: true},
+		{config: Config{Provider: "gke", Method: HTTP}, want: HTTP},
+		{config: Config{Provider: "gce", Method: "telnet"}, wantErr: true},
+	}
+	for _, c := range cases {
+		access, err := New(&c.config)
+		if c.wantErr {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, access.String())
+	}
+}
+
+func TestCheckCommandNotFound(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "no curl",
+			err:     utilexec.CodeExitError{Err: fmt.Errorf("command terminated with non-zero exit code"), Code: commandNotFoundCode},
+			wantErr: true,
+		},
+		{
+			name: "curl failure",
+			err:  utilexec.CodeExitError{Err: fmt.Errorf("command terminated with non-zero exit code"), Code: 22},
+		},
+		{
+			name:    "no shell",
+			err:     fmt.Errorf(`OCI runtime exec failed: exec failed: container_linux.go:345: starting container process caused "exec: \"/bin/sh\": stat /bin/sh: no such file or directory": unknown`),
+			wantErr: true,
+		},
+		{
+			name: "pod not found",
+			err:  fmt.Errorf(`pods "etcd-master" not found`),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkCommandNotFound("etcd", curlCommand("GET", 2379, "/metrics"), c.err, "")
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
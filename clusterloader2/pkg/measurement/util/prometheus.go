@@ -27,8 +27,8 @@ import (
 	"github.com/prometheus/common/expfmt"
 	"github.com/prometheus/common/model"
 	"k8s.io/apimachinery/pkg/util/wait"
-	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 )
 
 const (
@@ -84,13 +84,13 @@ type promResponseData struct {
 }
 
 // NewQueryExecutor creates instance of PrometheusQueryExecutor.
-func NewQueryExecutor(c clientset.Interface) *PrometheusQueryExecutor {
+func NewQueryExecutor(c prometheus.Client) *PrometheusQueryExecutor {
 	return &PrometheusQueryExecutor{client: c}
 }
 
-// PrometheusQueryExecutor executes queries against Prometheus instance running inside test cluster.
+// PrometheusQueryExecutor executes queries against Prometheus instance used by the test.
 type PrometheusQueryExecutor struct {
-	client clientset.Interface
+	client prometheus.Client
 }
 
 // Query executes given prometheus query at given point in time.
@@ -107,10 +107,7 @@ func (e *PrometheusQueryExecutor) Query(query string, queryTime time.Time) ([]*m
 	}
 	klog.Infof("Executing %q at %v", query, queryTime.Format(time.RFC3339))
 	if err := wait.PollImmediate(queryInterval, queryTimeout, func() (bool, error) {
-		body, queryErr = e.client.CoreV1().
-			Services("monitoring").
-			ProxyGet("http", "prometheus-k8s", "9090", "api/v1/query", params).
-			DoRaw()
+		body, queryErr = e.client.Get("api/v1/query", params)
 		if queryErr != nil {
 			return false, nil
 		}
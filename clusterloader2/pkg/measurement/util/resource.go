@@ -44,10 +44,31 @@ type UsageDataPerContainer struct {
 	MemWorkSetData []uint64
 }
 
+// NodeResourceUsage represents resource usage by a single node.
+// Disk and network usage is measured as a throughput (in bytes per second).
+type NodeResourceUsage struct {
+	Name                    string
+	Timestamp               time.Time
+	CPUUsageInCores         float64
+	MemoryWorkingSetInBytes uint64
+	DiskReadBytesPerSecond  float64
+	DiskWriteBytesPerSecond float64
+	NetworkRxBytesPerSecond float64
+	NetworkTxBytesPerSecond float64
+}
+
+// ResourceUsagePerNode is a map of NodeResourceUsage for nodes.
+type ResourceUsagePerNode map[string]*NodeResourceUsage
+
 // ResourceConstraint specifies constraint on resources.
+// Disk and network constraints (in bytes per second) apply to nodes only.
 type ResourceConstraint struct {
-	CPUConstraint    float64 `json: cpuConstraint`
-	MemoryConstraint uint64  `json: memoryConstraint`
+	CPUConstraint       float64 `json: cpuConstraint`
+	MemoryConstraint    uint64  `json: memoryConstraint`
+	DiskReadConstraint  float64 `json:"diskReadConstraint"`
+	DiskWriteConstraint float64 `json:"diskWriteConstraint"`
+	NetworkRxConstraint float64 `json:"networkRxConstraint"`
+	NetworkTxConstraint float64 `json:"networkTxConstraint"`
 }
 
 // SingleContainerSummary is a resource usage summary for a single container.
@@ -57,6 +78,34 @@ type SingleContainerSummary struct {
 	Mem  uint64
 }
 
+// ContainerMemoryHighWaterMark represents maximum memory usage observed for a single container.
+type ContainerMemoryHighWaterMark struct {
+	Name                 string `json:"name"`
+	MaxWorkingSetInBytes uint64 `json:"maxWorkingSetInBytes"`
+	MaxRSSInBytes        uint64 `json:"maxRSSInBytes"`
+}
+
+// Update updates the high-water marks with the given usage.
+func (m *ContainerMemoryHighWaterMark) Update(workingSetInBytes, rssInBytes uint64) {
+	if workingSetInBytes > m.MaxWorkingSetInBytes {
+		m.MaxWorkingSetInBytes = workingSetInBytes
+	}
+	if rssInBytes > m.MaxRSSInBytes {
+		m.MaxRSSInBytes = rssInBytes
+	}
+}
+
+// SingleNodeSummary is a resource usage summary for a single node.
+type SingleNodeSummary struct {
+	Name      string
+	Cpu       float64
+	Mem       uint64
+	DiskRead  float64
+	DiskWrite float64
+	NetworkRx float64
+	NetworkTx float64
+}
+
 type uint64arr []uint64
 
 func (a uint64arr) Len() int           { return len(a) }
@@ -120,3 +169,57 @@ func LeftMergeData(left, right map[int]ResourceUsagePerContainer) map[int]Resour
 	}
 	return result
 }
+
+// ComputeNodePercentiles calculates percentiles for given node data series.
+// Percentiles of every resource are computed independently.
+func ComputeNodePercentiles(timeSeries []ResourceUsagePerNode, percentilesToCompute []int) map[int]ResourceUsagePerNode {
+	type nodeUsageData struct {
+		cpu, diskRead, diskWrite, networkRx, networkTx []float64
+		mem                                            []uint64
+	}
+	dataMap := make(map[string]*nodeUsageData)
+	for i := range timeSeries {
+		for name, data := range timeSeries[i] {
+			if dataMap[name] == nil {
+				dataMap[name] = &nodeUsageData{}
+			}
+			d := dataMap[name]
+			d.cpu = append(d.cpu, data.CPUUsageInCores)
+			d.mem = append(d.mem, data.MemoryWorkingSetInBytes)
+			d.diskRead = append(d.diskRead, data.DiskReadBytesPerSecond)
+			d.diskWrite = append(d.diskWrite, data.DiskWriteBytesPerSecond)
+			d.networkRx = append(d.networkRx, data.NetworkRxBytesPerSecond)
+			d.networkTx = append(d.networkTx, data.NetworkTxBytesPerSecond)
+		}
+	}
+	for _, d := range dataMap {
+		sort.Float64s(d.cpu)
+		sort.Sort(uint64arr(d.mem))
+		sort.Float64s(d.diskRead)
+		sort.Float64s(d.diskWrite)
+		sort.Float64s(d.networkRx)
+		sort.Float64s(d.networkTx)
+	}
+
+	result := make(map[int]ResourceUsagePerNode)
+	for _, perc := range percentilesToCompute {
+		data := make(ResourceUsagePerNode)
+		for k, d := range dataMap {
+			percentileIndex := int(math.Ceil(float64(len(d.cpu)*perc)/100)) - 1
+			if percentileIndex < 0 {
+				percentileIndex = 0
+			}
+			data[k] = &NodeResourceUsage{
+				Name:                    k,
+				CPUUsageInCores:         d.cpu[percentileIndex],
+				MemoryWorkingSetInBytes: d.mem[percentileIndex],
+				DiskReadBytesPerSecond:  d.diskRead[percentileIndex],
+				DiskWriteBytesPerSecond: d.diskWrite[percentileIndex],
+				NetworkRxBytesPerSecond: d.networkRx[percentileIndex],
+				NetworkTxBytesPerSecond: d.networkTx[percentileIndex],
+			}
+		}
+		result[perc] = data
+	}
+	return result
+}
@@ -18,6 +18,7 @@ package runtimeobjects
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -29,7 +30,10 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
 )
 
@@ -113,6 +117,32 @@ func ListRuntimeObjectsForKind(c clientset.Interface, kind, namespace, labelSele
 	return runtimeObjectsList, nil
 }
 
+// ListRuntimeObjectsForResource returns objects of given resource that satisfy given namespace, labelSelector and fieldSelector.
+// Contrary to ListRuntimeObjectsForKind, any resource (including custom resources) is supported.
+// Returned objects are *unstructured.Unstructured.
+func ListRuntimeObjectsForResource(c dynamic.Interface, gvr schema.GroupVersionResource, namespace, labelSelector, fieldSelector string) ([]runtime.Object, error) {
+	var runtimeObjectsList []runtime.Object
+	listOpts := metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	}
+	listFunc := func() error {
+		list, err := c.Resource(gvr).Namespace(namespace).List(listOpts)
+		if err != nil {
+			return err
+		}
+		runtimeObjectsList = make([]runtime.Object, len(list.Items))
+		for i := range list.Items {
+			runtimeObjectsList[i] = &list.Items[i]
+		}
+		return nil
+	}
+	if err := client.RetryWithExponentialBackOff(client.RetryFunction(listFunc)); err != nil {
+		return nil, err
+	}
+	return runtimeObjectsList, nil
+}
+
 // GetNameFromRuntimeObject returns name of given runtime object.
 func GetNameFromRuntimeObject(obj runtime.Object) (string, error) {
 	switch typed := obj.(type) {
@@ -313,6 +343,109 @@ func tryAcquireReplicasFromUnstructuredSpec(spec map[string]interface{}, kind st
 	}
 }
 
+// GetReplicasFromRuntimeObjectWithJSONPath returns replicas number read from given JSONPath
+// (e.g. {.spec.replicas}) of given runtime object. Missing field means 0 replicas.
+// If path is empty, GetReplicasFromRuntimeObject is used.
+func GetReplicasFromRuntimeObjectWithJSONPath(obj runtime.Object, path string) (int32, error) {
+	if obj == nil {
+		return 0, nil
+	}
+	if path == "" {
+		return GetReplicasFromRuntimeObject(obj)
+	}
+	value, found, err := findJSONPathValue(obj, path)
+	if err != nil {
+		return -1, err
+	}
+	if !found {
+		return 0, nil
+	}
+	switch typed := value.(type) {
+	case int64:
+		return int32(typed), nil
+	case int32:
+		return typed, nil
+	case int:
+		return int32(typed), nil
+	case float64:
+		return int32(typed), nil
+	default:
+		return -1, fmt.Errorf("unsupported replicas type %T at %s", value, path)
+	}
+}
+
+// GetSelectorFromRuntimeObjectWithJSONPath returns selector read from given JSONPath
+// (e.g. {.spec.selector}) of given runtime object. Selector can be either a label selector
+// (with matchLabels and/or matchExpressions), a map of labels or a selector string.
+// If path is empty, GetSelectorFromRuntimeObject is used.
+func GetSelectorFromRuntimeObjectWithJSONPath(obj runtime.Object, path string) (labels.Selector, error) {
+	if path == "" {
+		return GetSelectorFromRuntimeObject(obj)
+	}
+	value, found, err := findJSONPathValue(obj, path)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("selector not found at %s", path)
+	}
+	switch typed := value.(type) {
+	case string:
+		return labels.Parse(typed)
+	case map[string]interface{}:
+		_, hasMatchLabels := typed["matchLabels"]
+		_, hasMatchExpressions := typed["matchExpressions"]
+		if hasMatchLabels || hasMatchExpressions {
+			var selector metav1.LabelSelector
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(typed, &selector); err != nil {
+				return nil, fmt.Errorf("label selector conversion error: %v", err)
+			}
+			return metav1.LabelSelectorAsSelector(&selector)
+		}
+		set := labels.Set{}
+		for key, val := range typed {
+			strVal, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported selector value type %T of label %s", val, key)
+			}
+			set[key] = strVal
+		}
+		return labels.SelectorFromSet(set), nil
+	default:
+		return nil, fmt.Errorf("unsupported selector type %T at %s", value, path)
+	}
+}
+
+// findJSONPathValue returns value at given JSONPath of given runtime object.
+func findJSONPathValue(obj runtime.Object, path string) (interface{}, bool, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, false, fmt.Errorf("unstructured conversion error: %v", err)
+	}
+	j := jsonpath.New("runtimeobject").AllowMissingKeys(true)
+	if err := j.Parse(path); err != nil {
+		return nil, false, fmt.Errorf("parsing JSONPath %s error: %v", path, err)
+	}
+	results, err := j.FindResults(content)
+	if err != nil {
+		return nil, false, fmt.Errorf("finding JSONPath %s error: %v", path, err)
+	}
+	if len(results) == 0 || len(results[0]) == 0 {
+		return nil, false, nil
+	}
+	if len(results) > 1 || len(results[0]) > 1 {
+		return nil, false, fmt.Errorf("JSONPath %s matches multiple values", path)
+	}
+	value := results[0][0]
+	if value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, false, nil
+		}
+		value = value.Elem()
+	}
+	return value.Interface(), true, nil
+}
+
 // IsEqualRuntimeObjectsSpec returns true if given runtime objects have identical specs.
 func IsEqualRuntimeObjectsSpec(runtimeObj1, runtimeObj2 runtime.Object) (bool, error) {
 	runtimeObj1Spec, err := GetSpecFromRuntimeObject(runtimeObj1)
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement/util/runtimeobjects"
@@ -330,3 +331,76 @@ func TestGetReplicasFromRuntimeObject(t *testing.T) {
 		}
 	}
 }
+
+var customResource = &unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "FooCluster",
+		"metadata": map[string]interface{}{
+			"name":      controllerName,
+			"namespace": testNamespace,
+		},
+		"spec": map[string]interface{}{
+			"size":      int64(defaultReplicas),
+			"podLabels": map[string]interface{}{"foo": "bar"},
+		},
+		"status": map[string]interface{}{
+			"selector": "foo=bar",
+		},
+	},
+}
+
+func TestGetReplicasFromRuntimeObjectWithJSONPath(t *testing.T) {
+	statefulSet := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(deployment, statefulSet, nil); err != nil {
+		t.Fatalf("error converting controller to unstructured: %v", err)
+	}
+	statefulSet.SetKind("StatefulSet")
+
+	cases := []struct {
+		obj      runtime.Object
+		path     string
+		expected int32
+	}{
+		{obj: statefulSet, path: "", expected: defaultReplicas},
+		{obj: statefulSet, path: "{.spec.replicas}", expected: defaultReplicas},
+		{obj: deployment, path: "{.spec.replicas}", expected: defaultReplicas},
+		{obj: customResource, path: "{.spec.size}", expected: defaultReplicas},
+		{obj: customResource, path: "{.spec.replicas}", expected: 0},
+	}
+	for _, c := range cases {
+		replicas, err := runtimeobjects.GetReplicasFromRuntimeObjectWithJSONPath(c.obj, c.path)
+		if err != nil {
+			t.Fatalf("get replicas from runtime object with path %q failed: %v", c.path, err)
+		}
+		if c.expected != replicas {
+			t.Fatalf("Unexpected replicas from runtime object with path %q, expected: %d, actual: %d", c.path, c.expected, replicas)
+		}
+	}
+}
+
+func TestGetSelectorFromRuntimeObjectWithJSONPath(t *testing.T) {
+	expected := labels.SelectorFromSet(simpleLabel)
+	cases := []struct {
+		obj  runtime.Object
+		path string
+	}{
+		{obj: deployment, path: "{.spec.selector}"},
+		{obj: replicationcontroller, path: "{.spec.selector}"},
+		{obj: customResource, path: "{.spec.podLabels}"},
+		{obj: customResource, path: "{.status.selector}"},
+	}
+	for _, c := range cases {
+		selector, err := runtimeobjects.GetSelectorFromRuntimeObjectWithJSONPath(c.obj, c.path)
+		if err != nil {
+			t.Fatalf("get selector from runtime object with path %q failed: %v", c.path, err)
+		}
+		if expected.String() != selector.String() {
+			t.Fatalf("Unexpected selector from runtime object with path %q, expected: %v, actual: %v", c.path, expected, selector)
+		}
+	}
+
+	if _, err := runtimeobjects.GetSelectorFromRuntimeObjectWithJSONPath(customResource, "{.spec.selector}"); err == nil {
+		t.Fatalf("expected error for missing selector")
+	}
+}
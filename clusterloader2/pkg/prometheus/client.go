@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/perf-tests/clusterloader2/pkg/config"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+)
+
+const externalRequestTimeout = time.Minute
+
+// Client sends requests to the Prometheus HTTP API.
+type Client interface {
+	// Get sends GET request to the given api path (e.g. api/v1/query) with the given query params.
+	Get(path string, params map[string]string) ([]byte, error)
+}
+
+// NewClient returns Client for the Prometheus used by the test:
+// the external one if PrometheusURL is set, the one set up by PrometheusController otherwise.
+// If neither is available, nil is returned.
+func NewClient(clusterLoaderConfig *config.ClusterLoaderConfig, prometheusFramework *framework.Framework) Client {
+	if clusterLoaderConfig.PrometheusURL != "" {
+		return NewExternalClient(clusterLoaderConfig.PrometheusURL, clusterLoaderConfig.PrometheusBearerTokenFile)
+	}
+	if prometheusFramework != nil {
+		return NewInClusterClient(prometheusFramework.GetClientSets().GetClient())
+	}
+	return nil
+}
+
+// NewInClusterClient creates Client reaching Prometheus set up by PrometheusController
+// through the api server service proxy.
+func NewInClusterClient(c kubernetes.Interface) Client {
+	return &inClusterClient{client: c}
+}
+
+type inClusterClient struct {
+	client kubernetes.Interface
+}
+
+func (c *inClusterClient) Get(path string, params map[string]string) ([]byte, error) {
+	return c.client.CoreV1().
+		Services(namespace).
+		ProxyGet("http", "prometheus-k8s", "9090", path, params).
+		DoRaw()
+}
+
+// NewExternalClient creates Client reaching Prometheus at the given url.
+// If bearerTokenFile is not empty, requests are authenticated with the token from that file.
+// The file is read on every request, so that the token can be rotated during the test.
+func NewExternalClient(prometheusURL, bearerTokenFile string) Client {
+	return &externalClient{
+		url:             strings.TrimSuffix(prometheusURL, "/"),
+		bearerTokenFile: bearerTokenFile,
+		httpClient:      &http.Client{Timeout: externalRequestTimeout},
+	}
+}
+
+type externalClient struct {
+	url             string
+	bearerTokenFile string
+	httpClient      *http.Client
+}
+
+func (c *externalClient) Get(path string, params map[string]string) ([]byte, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	requestURL := fmt.Sprintf("%s/%s", c.url, strings.TrimPrefix(path, "/"))
+	if len(values) > 0 {
+		requestURL += "?" + values.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.bearerTokenFile != "" {
+		token, err := ioutil.ReadFile(c.bearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading bearer token error: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request to %s failed with status %s: %s", path, resp.Status, string(body))
+	}
+	return body, nil
+}
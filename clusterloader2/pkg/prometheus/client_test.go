@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalClientGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "%s query=%s auth=%s", r.Method, r.URL.Query().Get("query"), r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	tokenFile, err := ioutil.TempFile("", "prometheus-token")
+	if err != nil {
+		t.Fatalf("creating token file error: %v", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	if _, err := tokenFile.WriteString("secret-token\n"); err != nil {
+		t.Fatalf("writing token file error: %v", err)
+	}
+	tokenFile.Close()
+
+	cases := []struct {
+		name            string
+		url             string
+		path            string
+		params          map[string]string
+		bearerTokenFile string
+		want            string
+		wantErr         bool
+	}{
+		{
+			name: "no params",
+			url:  server.URL + "/prometheus",
+			path: "api/v1/query",
+			want: "GET query= auth=",
+		},
+		{
+			name:   "slashes joined",
+			url:    server.URL + "/prometheus/",
+			path:   "/api/v1/query",
+			params: map[string]string{"query": "up{job=\"a b\"}"},
+			want:   "GET query=up{job=\"a b\"} auth=",
+		},
+		{
+			name:            "bearer token",
+			url:             server.URL + "/prometheus",
+			path:            "api/v1/query",
+			bearerTokenFile: tokenFile.Name(),
+			want:            "GET query= auth=Bearer secret-token",
+		},
+		{
+			name:            "missing token file",
+			url:             server.URL + "/prometheus",
+			path:            "api/v1/query",
+			bearerTokenFile: tokenFile.Name() + "-missing",
+			wantErr:         true,
+		},
+		{
+			name:    "non-2xx status",
+			url:     server.URL + "/prometheus",
+			path:    "api/v1/unknown",
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body, err := NewExternalClient(c.url, c.bearerTokenFile).Get(c.path, c.params)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, string(body))
+		})
+	}
+}
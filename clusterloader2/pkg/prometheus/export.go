@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"k8s.io/klog"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+)
+
+const (
+	prometheusPod       = "prometheus-k8s-0"
+	prometheusContainer = "prometheus"
+	tsdbPath            = "/prometheus"
+	snapshotDirName     = "PrometheusSnapshot"
+)
+
+// ExportedSeries is a result of the range query.
+type ExportedSeries struct {
+	Query  string       `json:"query"`
+	Result model.Matrix `json:"result"`
+}
+
+// ExportedData represents results of the range queries exported over the test run.
+type ExportedData struct {
+	Start  time.Time        `json:"start"`
+	End    time.Time        `json:"end"`
+	Step   string           `json:"step"`
+	Series []ExportedSeries `json:"series"`
+}
+
+type rangeQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     model.Matrix `json:"result"`
+	} `json:"data"`
+}
+
+// ExportRangeQueries evaluates the queries over [start, end] with the given step
+// and writes results in JSON to the given file.
+// Queries that fail are logged and skipped, so that single broken query doesn't prevent the export.
+func ExportRangeQueries(c Client, queries []string, start, end time.Time, step time.Duration, fileName string) error {
+	data := &ExportedData{Start: start, End: end, Step: step.String()}
+	for _, query := range queries {
+		result, err := rangeQuery(c, query, start, end, step)
+		if err != nil {
+			klog.Errorf("Exporting %q error: %v", query, err)
+			continue
+		}
+		data.Series = append(data.Series, ExportedSeries{Query: query, Result: result})
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Writing %d exported series to %s", len(data.Series), fileName)
+	return ioutil.WriteFile(fileName, content, 0644)
+}
+
+func rangeQuery(c Client, query string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	params := map[string]string{
+		"query": query,
+		"start": start.Format(time.RFC3339),
+		"end":   end.Format(time.RFC3339),
+		"step":  strconv.FormatFloat(step.Seconds(), 'f', -1, 64),
+	}
+	body, err := c.Get("api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+	var response rangeQueryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("non-success response status %q: %s", response.Status, response.Error)
+	}
+	if response.Data.ResultType != model.ValMatrix.String() {
+		return nil, fmt.Errorf("unexpected result type %q", response.Data.ResultType)
+	}
+	return response.Data.Result, nil
+}
+
+type snapshotResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Name string `json:"name"`
+	} `json:"data"`
+}
+
+// ExportSnapshot creates TSDB snapshot with the Prometheus admin API and copies it
+// (streaming tar archive through the pod exec) to the PrometheusSnapshot directory in the given dir.
+// The snapshot can be loaded by any Prometheus server started with it as the storage path.
+func (pc *PrometheusController) ExportSnapshot(dir string) error {
+	klog.Info("Creating Prometheus TSDB snapshot")
+	body, err := pc.framework.GetClientSets().GetClient().CoreV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("services").
+		Name("http:prometheus-k8s:9090").
+		SubResource("proxy").
+		Suffix("api/v1/admin/tsdb/snapshot").
+		DoRaw()
+	if err != nil {
+		return fmt.Errorf("snapshot creation error: %v, response: %s", err, string(body))
+	}
+	var response snapshotResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	if response.Status != "success" || response.Data.Name == "" {
+		return fmt.Errorf("snapshot creation failed: %s", string(body))
+	}
+
+	destination := path.Join(dir, snapshotDirName)
+	klog.Infof("Copying Prometheus snapshot %s to %s", response.Data.Name, destination)
+	restConfig, err := pc.framework.GetRestClientConfig()
+	if err != nil {
+		return err
+	}
+	command := []string{"tar", "cf", "-", "-C", path.Join(tsdbPath, "snapshots", response.Data.Name), "."}
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	errCh := make(chan error, 1)
+	go func() {
+		err := client.ExecInPod(pc.framework.GetClientSets().GetClient(), restConfig, namespace, prometheusPod, prometheusContainer, command, writer, &stderr, nil)
+		writer.CloseWithError(err)
+		errCh <- err
+	}()
+	untarErr := untar(reader, destination)
+	// Unblocks the exec stream if untar stopped before the end of the archive.
+	reader.CloseWithError(untarErr)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("copying snapshot error: %v, stderr: %s", err, stderr.String())
+	}
+	if untarErr != nil {
+		return fmt.Errorf("extracting snapshot error: %v", untarErr)
+	}
+	return nil
+}
+
+// untar extracts directories and regular files from the tar archive to the given dir.
+func untar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, header.Name)
+		if target != filepath.Clean(dir) && !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %q is outside of the destination dir", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr); err != nil {
+				return err
+			}
+		default:
+			klog.Warningf("Skipping archive entry %q of type %v", header.Name, header.Typeflag)
+		}
+	}
+}
+
+func writeFile(name string, r io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const matrixResponse = `{
+  "status": "success",
+  "data": {
+    "resultType": "matrix",
+    "result": [{"metric": {"job": "apiserver"}, "values": [[1546300800, "1"], [1546300830, "2"]]}]
+  }
+}`
+
+// fakeClient returns responses to the queries from the responses map.
+type fakeClient struct {
+	responses map[string]string
+	requests  []map[string]string
+}
+
+func (f *fakeClient) Get(path string, params map[string]string) ([]byte, error) {
+	if path != "api/v1/query_range" {
+		return nil, fmt.Errorf("unexpected path %q", path)
+	}
+	f.requests = append(f.requests, params)
+	response, ok := f.responses[params["query"]]
+	if !ok {
+		return nil, fmt.Errorf("unknown query %q", params["query"])
+	}
+	return []byte(response), nil
+}
+
+func TestRangeQuery(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	cases := []struct {
+		name       string
+		response   string
+		wantSeries int
+		wantErr    bool
+	}{
+		{name: "success", response: matrixResponse, wantSeries: 1},
+		{name: "error status", response: `{"status": "error", "error": "parse error"}`, wantErr: true},
+		{name: "vector result", response: `{"status": "success", "data": {"resultType": "vector", "result": []}}`, wantErr: true},
+		{name: "invalid json", response: `{`, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &fakeClient{responses: map[string]string{"up": c.response}}
+			result, err := rangeQuery(client, "up", start, end, 30*time.Second)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, result, c.wantSeries)
+			assert.Equal(t, []map[string]string{{
+				"query": "up",
+				"start": "2019-01-01T00:00:00Z",
+				"end":   "2019-01-01T00:01:00Z",
+				"step":  "30",
+			}}, client.requests)
+		})
+	}
+}
+
+func TestExportRangeQueries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prometheus-export")
+	if err != nil {
+		t.Fatalf("creating temp dir error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := path.Join(dir, "PrometheusData.json")
+	client := &fakeClient{responses: map[string]string{
+		"up":      matrixResponse,
+		"failing": `{"status": "error", "error": "query timed out"}`,
+	}}
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err = ExportRangeQueries(client, []string{"failing", "up", "unknown"}, start, start.Add(time.Minute), 30*time.Second, fileName)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("reading exported data error: %v", err)
+	}
+	var data ExportedData
+	if err := json.Unmarshal(content, &data); err != nil {
+		t.Fatalf("unmarshalling exported data error: %v", err)
+	}
+	assert.Equal(t, "30s", data.Step)
+	// Broken queries are skipped.
+	if assert.Len(t, data.Series, 1) {
+		assert.Equal(t, "up", data.Series[0].Query)
+		assert.Len(t, data.Series[0].Result, 1)
+	}
+	assert.Len(t, client.requests, 3)
+}
+
+func newTestArchive(t *testing.T, files map[string]string, dirs ...string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, dir := range dirs {
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatalf("writing archive error: %v", err)
+		}
+	}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("writing archive error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("writing archive error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing archive error: %v", err)
+	}
+	return &buf
+}
+
+func TestUntar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("creating temp dir error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := newTestArchive(t, map[string]string{"./01ABC/index": "index", "./01ABC/chunks/000001": "chunk"}, "./", "./01ABC/")
+	destination := path.Join(dir, snapshotDirName)
+	assert.NoError(t, untar(archive, destination))
+	for name, want := range map[string]string{"01ABC/index": "index", "01ABC/chunks/000001": "chunk"} {
+		content, err := ioutil.ReadFile(path.Join(destination, name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+
+	archive = newTestArchive(t, map[string]string{"../outside": "content"})
+	assert.Error(t, untar(archive, destination))
+	_, err = os.Stat(path.Join(dir, "outside"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -47,6 +47,7 @@ const (
 	numK8sClients                = 1
 	nodeExporterPod              = "$GOPATH/src/k8s.io/perf-tests/clusterloader2/pkg/prometheus/manifests/exporters/node-exporter.yaml"
 	nodeExporterTemplateName     = "PROMETHEUS_SCRAPE_NODE_EXPORTER"
+	adminAPITemplateName         = "PROMETHEUS_ENABLE_ADMIN_API"
 )
 
 // PrometheusController is a util for managing (setting up / tearing down) the prometheus stack in
@@ -60,6 +61,8 @@ type PrometheusController struct {
 	framework *framework.Framework
 	// templateMapping is a mapping defining placeholders used in manifest templates.
 	templateMapping map[string]interface{}
+	// testNamespaces are namespaces in which prometheus was granted permissions for the test objects.
+	testNamespaces map[string]bool
 }
 
 // NewPrometheusController creates a new instance of PrometheusController for the given config.
@@ -82,6 +85,10 @@ func NewPrometheusController(clusterLoaderConfig *config.ClusterLoaderConfig) (p
 		klog.Warningf("Couldn't get master ip, will ignore manifests requiring it: %v", err)
 		delete(mapping, "MasterIps")
 	}
+	if clusterLoaderConfig.PrometheusExportSnapshot {
+		// Admin API is required to create TSDB snapshot.
+		mapping[adminAPITemplateName] = true
+	}
 	pc.templateMapping = mapping
 
 	return pc, nil
@@ -162,7 +169,7 @@ func (pc *PrometheusController) exposeKubemarkApiServerMetrics() error {
 	klog.Info("Exposing kube-apiserver metrics in kubemark cluster")
 	// This has to be done in the kubemark cluster, thus we need to create a new client.
 	clientSet, err := framework.NewMultiClientSet(
-		pc.clusterLoaderConfig.ClusterConfig.KubeConfigPath, numK8sClients)
+		pc.clusterLoaderConfig.ClusterConfig.KubeConfigPath, numK8sClients, &pc.clusterLoaderConfig.ClusterConfig.ClientConfig)
 	if err != nil {
 		return err
 	}
@@ -249,7 +256,7 @@ func (pc *PrometheusController) isPrometheusReady() (bool, error) {
 	// prometheus, prometheus-operator, grafana, apiserver
 	expectedTargets := 4
 	return CheckTargetsReady(
-		pc.framework.GetClientSets().GetClient(),
+		NewInClusterClient(pc.framework.GetClientSets().GetClient()),
 		func(Target) bool { return true }, // All targets.
 		expectedTargets)
 }
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+)
+
+const (
+	serviceMonitorKind = "ServiceMonitor"
+	podMonitorKind     = "PodMonitor"
+	prometheusRuleKind = "PrometheusRule"
+
+	testRBACName = "prometheus-k8s-test"
+)
+
+// Namespaces in which prometheus has permissions to discover targets granted by the manifests.
+var defaultDiscoveryNamespaces = map[string]bool{"default": true, "kube-system": true, namespace: true}
+
+// ApplyTestObjects applies ServiceMonitors and PrometheusRules declared by the test.
+// Objects without namespace are created in the monitoring namespace. PrometheusRules are labeled
+// to be selected by the Prometheus server. If ServiceMonitor selects targets in namespaces
+// other than default, kube-system and monitoring, prometheus is granted permissions to discover them.
+// Objects are modified accordingly, so that they can be passed to DeleteTestObjects afterwards.
+func (pc *PrometheusController) ApplyTestObjects(objects []*unstructured.Unstructured) error {
+	if err := validateTestObjects(objects); err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		prepareTestObject(obj)
+		if obj.GetKind() == serviceMonitorKind {
+			namespaces, err := targetNamespaces(obj)
+			if err != nil {
+				return err
+			}
+			for _, ns := range namespaces {
+				if err := pc.grantDiscoveryPermissions(ns); err != nil {
+					return fmt.Errorf("granting permissions in %s namespace error: %v", ns, err)
+				}
+			}
+		}
+		klog.Infof("Applying %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		if err := pc.framework.CreateObject(obj.GetNamespace(), obj.GetName(), obj); err != nil {
+			return fmt.Errorf("%s %s/%s creation error: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// validateTestObjects checks whether all objects are of the supported kinds.
+func validateTestObjects(objects []*unstructured.Unstructured) error {
+	for _, obj := range objects {
+		switch obj.GetKind() {
+		case serviceMonitorKind, prometheusRuleKind:
+		case podMonitorKind:
+			return fmt.Errorf("%s %s: PodMonitors are not supported by the prometheus-operator version used by the prometheus stack", obj.GetKind(), obj.GetName())
+		default:
+			return fmt.Errorf("%s %s: unsupported prometheus object kind", obj.GetKind(), obj.GetName())
+		}
+	}
+	return nil
+}
+
+// prepareTestObject sets the default namespace of the object and labels PrometheusRules.
+func prepareTestObject(obj *unstructured.Unstructured) {
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+	if obj.GetKind() != prometheusRuleKind {
+		return
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	// Labels matching ruleSelector of the Prometheus server.
+	labels["prometheus"] = "k8s"
+	labels["role"] = "alert-rules"
+	obj.SetLabels(labels)
+}
+
+// DeleteTestObjects deletes objects applied by ApplyTestObjects and permissions granted for them.
+func (pc *PrometheusController) DeleteTestObjects(objects []*unstructured.Unstructured) error {
+	var errs []error
+	for _, obj := range objects {
+		klog.Infof("Deleting %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		if err := pc.framework.DeleteObject(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName()); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s/%s deletion error: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err))
+		}
+	}
+	k8sClient := pc.framework.GetClientSets().GetClient()
+	for ns := range pc.testNamespaces {
+		if err := k8sClient.RbacV1().RoleBindings(ns).Delete(testRBACName, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+		if err := k8sClient.RbacV1().Roles(ns).Delete(testRBACName, &metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	pc.testNamespaces = nil
+	if len(errs) > 0 {
+		return fmt.Errorf("deleting prometheus objects errors: %v", errs)
+	}
+	return nil
+}
+
+// targetNamespaces returns namespaces in which ServiceMonitor looks for the targets.
+func targetNamespaces(obj *unstructured.Unstructured) ([]string, error) {
+	selectAll, _, err := unstructured.NestedBool(obj.Object, "spec", "namespaceSelector", "any")
+	if err != nil {
+		return nil, err
+	}
+	if selectAll {
+		klog.Warningf("%s %s/%s selects all namespaces, only targets in default, kube-system and monitoring namespaces will be discovered",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		return nil, nil
+	}
+	namespaces, found, err := unstructured.NestedStringSlice(obj.Object, "spec", "namespaceSelector", "matchNames")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return []string{obj.GetNamespace()}, nil
+	}
+	return namespaces, nil
+}
+
+// grantDiscoveryPermissions allows prometheus to discover targets in the given namespace.
+func (pc *PrometheusController) grantDiscoveryPermissions(ns string) error {
+	if defaultDiscoveryNamespaces[ns] || pc.testNamespaces[ns] {
+		return nil
+	}
+	klog.Infof("Granting prometheus permissions to discover targets in %s namespace", ns)
+	k8sClient := pc.framework.GetClientSets().GetClient()
+	createRole := func() error {
+		_, err := k8sClient.RbacV1().Roles(ns).Create(&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: testRBACName},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"services", "endpoints", "pods"}, Verbs: []string{"get", "list", "watch"}},
+			},
+		})
+		return err
+	}
+	createRoleBinding := func() error {
+		_, err := k8sClient.RbacV1().RoleBindings(ns).Create(&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: testRBACName},
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: testRBACName},
+			Subjects: []rbacv1.Subject{
+				{Kind: "ServiceAccount", Name: "prometheus-k8s", Namespace: namespace},
+			},
+		})
+		return err
+	}
+	if pc.testNamespaces == nil {
+		pc.testNamespaces = make(map[string]bool)
+	}
+	pc.testNamespaces[ns] = true
+	if err := retryCreateFunction(createRole); err != nil {
+		return err
+	}
+	return retryCreateFunction(createRoleBinding)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestObject(kind, ns string, labels map[string]interface{}, spec map[string]interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": "test"}
+	if ns != "" {
+		metadata["namespace"] = ns
+	}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       kind,
+		"metadata":   metadata,
+	}}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+func TestTargetNamespaces(t *testing.T) {
+	cases := []struct {
+		name    string
+		obj     *unstructured.Unstructured
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no namespace selector",
+			obj:  newTestObject(serviceMonitorKind, "test-ns", nil, map[string]interface{}{}),
+			want: []string{"test-ns"},
+		},
+		{
+			name: "match names",
+			obj: newTestObject(serviceMonitorKind, "monitoring", nil, map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"ns-1", "ns-2"}},
+			}),
+			want: []string{"ns-1", "ns-2"},
+		},
+		{
+			name: "any namespace",
+			obj: newTestObject(serviceMonitorKind, "monitoring", nil, map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"any": true, "matchNames": []interface{}{"ns-1"}},
+			}),
+			want: nil,
+		},
+		{
+			name: "any set to false",
+			obj: newTestObject(serviceMonitorKind, "monitoring", nil, map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"any": false},
+			}),
+			want: []string{"monitoring"},
+		},
+		{
+			name: "invalid any",
+			obj: newTestObject(serviceMonitorKind, "monitoring", nil, map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"any": "yes"},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "invalid match names",
+			obj: newTestObject(serviceMonitorKind, "monitoring", nil, map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"matchNames": "ns-1"},
+			}),
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := targetNamespaces(c.obj)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestPrepareTestObject(t *testing.T) {
+	cases := []struct {
+		name          string
+		obj           *unstructured.Unstructured
+		wantNamespace string
+		wantLabels    map[string]string
+	}{
+		{
+			name:          "rule without labels",
+			obj:           newTestObject(prometheusRuleKind, "", nil, nil),
+			wantNamespace: namespace,
+			wantLabels:    map[string]string{"prometheus": "k8s", "role": "alert-rules"},
+		},
+		{
+			name:          "rule with labels",
+			obj:           newTestObject(prometheusRuleKind, "test-ns", map[string]interface{}{"app": "test", "role": "recording-rules"}, nil),
+			wantNamespace: "test-ns",
+			wantLabels:    map[string]string{"app": "test", "prometheus": "k8s", "role": "alert-rules"},
+		},
+		{
+			name:          "service monitor",
+			obj:           newTestObject(serviceMonitorKind, "", map[string]interface{}{"app": "test"}, nil),
+			wantNamespace: namespace,
+			wantLabels:    map[string]string{"app": "test"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			prepareTestObject(c.obj)
+			assert.Equal(t, c.wantNamespace, c.obj.GetNamespace())
+			assert.Equal(t, c.wantLabels, c.obj.GetLabels())
+		})
+	}
+}
+
+func TestValidateTestObjects(t *testing.T) {
+	cases := []struct {
+		name    string
+		kinds   []string
+		wantErr bool
+	}{
+		{name: "supported kinds", kinds: []string{serviceMonitorKind, prometheusRuleKind}},
+		{name: "pod monitor", kinds: []string{serviceMonitorKind, podMonitorKind}, wantErr: true},
+		{name: "unknown kind", kinds: []string{"Prometheus"}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []*unstructured.Unstructured
+			for _, kind := range c.kinds {
+				objects = append(objects, newTestObject(kind, "", nil, nil))
+			}
+			err := validateTestObjects(objects)
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
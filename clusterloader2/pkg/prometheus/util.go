@@ -18,7 +18,9 @@ package prometheus
 
 import (
 	"encoding/json"
-	"k8s.io/client-go/kubernetes"
+	"fmt"
+	"time"
+
 	"k8s.io/klog"
 )
 
@@ -38,11 +40,8 @@ type Target struct {
 
 // CheckTargetsReady returns true iff there is at least minExpectedTargets matching the selector and
 // all of them are ready.
-func CheckTargetsReady(k8sClient kubernetes.Interface, selector func(Target) bool, minExpectedTargets int) (bool, error) {
-	raw, err := k8sClient.CoreV1().
-		Services(namespace).
-		ProxyGet("http", "prometheus-k8s", "9090", "api/v1/targets", nil /*params*/).
-		DoRaw()
+func CheckTargetsReady(c Client, selector func(Target) bool, minExpectedTargets int) (bool, error) {
+	raw, err := c.Get("api/v1/targets", nil /*params*/)
 	if err != nil {
 		// This might happen if prometheus server is temporary down, log error but don't return it.
 		klog.Warningf("error while calling prometheus api: %v", err)
@@ -74,3 +73,37 @@ func CheckTargetsReady(k8sClient kubernetes.Interface, selector func(Target) boo
 	klog.Infof("All %d targets are ready", nTotal)
 	return true, nil
 }
+
+type alertsResponse struct {
+	Status string     `json:"status"`
+	Data   alertsData `json:"data"`
+}
+
+type alertsData struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// Alert represents a Prometheus alert object.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	// State is either pending or firing.
+	State    string    `json:"state"`
+	ActiveAt time.Time `json:"activeAt"`
+}
+
+// GetAlerts returns alerts that are currently pending or firing.
+func GetAlerts(c Client) ([]Alert, error) {
+	raw, err := c.Get("api/v1/alerts", nil /*params*/)
+	if err != nil {
+		return nil, err
+	}
+	var response alertsResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("non-success response status: %v", response.Status)
+	}
+	return response.Data.Alerts, nil
+}
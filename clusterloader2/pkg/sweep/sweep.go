@@ -0,0 +1,232 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+	measurementutil "k8s.io/perf-tests/clusterloader2/pkg/measurement/util"
+)
+
+var dirNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._=-]+`)
+
+// Sweep defines combinations of template variables, for which tests are run.
+type Sweep struct {
+	// Matrix defines values of every variable. Tests are run for every
+	// combination of the values (cartesian product).
+	Matrix map[string][]interface{} `json:"matrix"`
+	// List defines combinations explicitly. They are run after the matrix ones.
+	List []map[string]interface{} `json:"list"`
+	// Metrics are the key metrics reported in the combined table.
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric specifies a value of the measurement summary (in PerfData format).
+type Metric struct {
+	// Name is the table column name. Defaults to Summary and Data.
+	Name string `json:"name"`
+	// Summary is the summary name prefix, e.g. PodStartupLatency.
+	Summary string `json:"summary"`
+	// Labels of the data item, e.g. Metric: pod_startup.
+	Labels map[string]string `json:"labels"`
+	// Data is the data item key, e.g. Perc99.
+	Data string `json:"data"`
+}
+
+func (m *Metric) getName() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return m.Summary + " " + m.Data
+}
+
+// Combination represents values of template variables used by a single run.
+type Combination struct {
+	Name   string
+	Values map[string]interface{}
+}
+
+// Dir returns name of the report subdirectory of the combination.
+func (c *Combination) Dir() string {
+	return dirNameRegexp.ReplaceAllString(strings.Replace(c.Name, ",", "_", -1), "-")
+}
+
+// Result represents outcome of a single test run.
+type Result struct {
+	Combination string
+	Test        string
+	Passed      bool
+	Duration    time.Duration
+	Metrics     map[string]float64
+}
+
+// Load reads sweep definition from the given file.
+func Load(path string) (*Sweep, error) {
+	bin, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("sweep reading error: %v", err)
+	}
+	sweep := &Sweep{}
+	if err = yaml.NewYAMLOrJSONDecoder(bytes.NewBuffer(bin), 4096).Decode(sweep); err != nil {
+		return nil, fmt.Errorf("sweep decoding error: %v", err)
+	}
+	for key, values := range sweep.Matrix {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no values of %s in sweep matrix", key)
+		}
+	}
+	for _, metric := range sweep.Metrics {
+		if metric.Summary == "" || metric.Data == "" {
+			return nil, fmt.Errorf("summary and data have to be specified for every sweep metric")
+		}
+	}
+	if len(sweep.Combinations()) == 0 {
+		return nil, fmt.Errorf("sweep doesn't define any combination")
+	}
+	return sweep, nil
+}
+
+// Combinations returns all combinations defined by the sweep.
+// Matrix combinations are ordered with the last variable (in alphabetical order) changing fastest.
+func (s *Sweep) Combinations() []Combination {
+	var combinations []Combination
+	if len(s.Matrix) > 0 {
+		keys := make([]string, 0, len(s.Matrix))
+		for key := range s.Matrix {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		products := []map[string]interface{}{{}}
+		for _, key := range keys {
+			var next []map[string]interface{}
+			for _, product := range products {
+				for _, value := range s.Matrix[key] {
+					values := map[string]interface{}{key: value}
+					for k, v := range product {
+						values[k] = v
+					}
+					next = append(next, values)
+				}
+			}
+			products = next
+		}
+		for _, values := range products {
+			combinations = append(combinations, newCombination(values))
+		}
+	}
+	for _, values := range s.List {
+		combinations = append(combinations, newCombination(values))
+	}
+	return combinations
+}
+
+func newCombination(values map[string]interface{}) Combination {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, values[key]))
+	}
+	return Combination{Name: strings.Join(parts, ","), Values: values}
+}
+
+// ExtractMetrics finds values of the sweep metrics in the given summary files.
+// The first data item matching the metric is used, metrics which are not found are omitted.
+func (s *Sweep) ExtractMetrics(files []string) map[string]float64 {
+	result := make(map[string]float64)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if filepath.Ext(name) != ".json" {
+			continue
+		}
+		var perfData *measurementutil.PerfData
+		for i := range s.Metrics {
+			metric := &s.Metrics[i]
+			if _, found := result[metric.getName()]; found || !strings.HasPrefix(name, metric.Summary+"_") {
+				continue
+			}
+			if perfData == nil {
+				perfData = &measurementutil.PerfData{}
+				bin, err := ioutil.ReadFile(file)
+				if err != nil || json.Unmarshal(bin, perfData) != nil {
+					// Not every summary is in PerfData format.
+					break
+				}
+			}
+			if value, ok := findValue(perfData, metric); ok {
+				result[metric.getName()] = value
+			}
+		}
+	}
+	return result
+}
+
+func findValue(perfData *measurementutil.PerfData, metric *Metric) (float64, bool) {
+	for _, item := range perfData.DataItems {
+		matches := true
+		for k, v := range metric.Labels {
+			if item.Labels[k] != v {
+				matches = false
+				break
+			}
+		}
+		if value, ok := item.Data[metric.Data]; matches && ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// WriteTable prints results as a table with a row per result.
+func (s *Sweep) WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	header := []string{"COMBINATION", "TEST", "STATUS", "DURATION"}
+	for i := range s.Metrics {
+		header = append(header, s.Metrics[i].getName())
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, result := range results {
+		status := "Fail"
+		if result.Passed {
+			status = "Success"
+		}
+		row := []string{result.Combination, result.Test, status, result.Duration.Round(time.Second).String()}
+		for i := range s.Metrics {
+			if value, ok := result.Metrics[s.Metrics[i].getName()]; ok {
+				row = append(row, fmt.Sprintf("%v", value))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sweep
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinations(t *testing.T) {
+	sweep := &Sweep{
+		Matrix: map[string][]interface{}{
+			"QPS":           {5, 20},
+			"PODS_PER_NODE": {10, 30},
+		},
+		List: []map[string]interface{}{{"PODS_PER_NODE": 50, "QPS": 1}},
+	}
+	var names []string
+	for _, combination := range sweep.Combinations() {
+		names = append(names, combination.Name)
+	}
+	assert.Equal(t, []string{
+		"PODS_PER_NODE=10,QPS=5",
+		"PODS_PER_NODE=10,QPS=20",
+		"PODS_PER_NODE=30,QPS=5",
+		"PODS_PER_NODE=30,QPS=20",
+		"PODS_PER_NODE=50,QPS=1",
+	}, names)
+
+	combination := newCombination(map[string]interface{}{"MODE": "a b/c", "QPS": 5})
+	assert.Equal(t, "MODE=a-b-c_QPS=5", combination.Dir())
+}
+
+func TestExtractMetricsAndWriteTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sweep")
+	if err != nil {
+		t.Fatalf("temp dir creation error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"PodStartupLatency_load_2019.json": `{"version": "v1", "dataItems": [
+			{"data": {"Perc99": 1}, "unit": "ms", "labels": {"Metric": "create_to_schedule"}},
+			{"data": {"Perc99": 2.5}, "unit": "ms", "labels": {"Metric": "pod_startup"}}]}`,
+		"ResourceUsageSummary_load_2019.json": `not perf data`,
+		"junit.xml":                           `<xml/>`,
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("file writing error: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	sweep := &Sweep{Metrics: []Metric{
+		{Name: "startup", Summary: "PodStartupLatency", Labels: map[string]string{"Metric": "pod_startup"}, Data: "Perc99"},
+		{Summary: "ResourceUsageSummary", Data: "Perc99"},
+	}}
+	metrics := sweep.ExtractMetrics(paths)
+	assert.Equal(t, map[string]float64{"startup": 2.5}, metrics)
+
+	var b bytes.Buffer
+	results := []Result{{Combination: "QPS=5", Test: "load", Passed: true, Duration: 90 * time.Second, Metrics: metrics}}
+	if err := sweep.WriteTable(&b, results); err != nil {
+		t.Fatalf("table writing error: %v", err)
+	}
+	assert.Equal(t, "COMBINATION  TEST  STATUS   DURATION  startup  ResourceUsageSummary Perc99\n"+
+		"QPS=5        load  Success  1m30s     2.5      -\n", b.String())
+}
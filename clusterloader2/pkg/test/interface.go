@@ -23,6 +23,7 @@ import (
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 	"k8s.io/perf-tests/clusterloader2/pkg/state"
 	"k8s.io/perf-tests/clusterloader2/pkg/tuningset"
 )
@@ -49,11 +50,13 @@ type Context interface {
 	GetClusterLoaderConfig() *config.ClusterLoaderConfig
 	GetClusterFramework() *framework.Framework
 	GetPrometheusFramework() *framework.Framework
+	GetPrometheusController() *prometheus.PrometheusController
 	GetState() *state.State
 	GetTemplateProvider() *config.TemplateProvider
 	GetTuningSetFactory() tuningset.TuningSetFactory
 	GetMeasurementManager() *measurement.MeasurementManager
 	GetChaosMonkey() *chaos.Monkey
+	IsTimestampAnnotationEnabled() bool
 }
 
 // TestExecutor is an interface for test executing object.
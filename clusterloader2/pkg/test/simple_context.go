@@ -23,32 +23,40 @@ import (
 	"k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
 	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 	"k8s.io/perf-tests/clusterloader2/pkg/state"
 	"k8s.io/perf-tests/clusterloader2/pkg/tuningset"
 )
 
 type simpleContext struct {
-	clusterLoaderConfig *config.ClusterLoaderConfig
-	clusterFramework    *framework.Framework
-	prometheusFramework *framework.Framework
-	state               *state.State
-	templateProvider    *config.TemplateProvider
-	tuningSetFactory    tuningset.TuningSetFactory
-	measurementManager  *measurement.MeasurementManager
-	chaosMonkey         *chaos.Monkey
+	clusterLoaderConfig  *config.ClusterLoaderConfig
+	clusterFramework     *framework.Framework
+	prometheusFramework  *framework.Framework
+	prometheusController *prometheus.PrometheusController
+	state                *state.State
+	templateProvider     *config.TemplateProvider
+	tuningSetFactory     tuningset.TuningSetFactory
+	measurementManager   *measurement.MeasurementManager
+	chaosMonkey          *chaos.Monkey
 }
 
-func createSimpleContext(c *config.ClusterLoaderConfig, f, p *framework.Framework, s *state.State) Context {
+func createSimpleContext(c *config.ClusterLoaderConfig, f *framework.Framework, pc *prometheus.PrometheusController, s *state.State) Context {
+	var p *framework.Framework
+	if pc != nil {
+		p = pc.GetFramework()
+	}
 	templateProvider := config.NewTemplateProvider(filepath.Dir(c.TestConfigPath))
+	chaosMonkey := chaos.NewMonkey(f.GetClientSets().GetClient(), &c.ClusterConfig)
 	return &simpleContext{
-		clusterLoaderConfig: c,
-		clusterFramework:    f,
-		prometheusFramework: p,
-		state:               s,
-		templateProvider:    templateProvider,
-		tuningSetFactory:    tuningset.NewTuningSetFactory(),
-		measurementManager:  measurement.CreateMeasurementManager(f, p, templateProvider, c),
-		chaosMonkey:         chaos.NewMonkey(f.GetClientSets().GetClient(), c.ClusterConfig.Provider),
+		clusterLoaderConfig:  c,
+		clusterFramework:     f,
+		prometheusFramework:  p,
+		prometheusController: pc,
+		state:                s,
+		templateProvider:     templateProvider,
+		tuningSetFactory:     tuningset.NewTuningSetFactory(),
+		measurementManager:   measurement.CreateMeasurementManager(f, p, templateProvider, c, chaosMonkey.GetEventRecorder()),
+		chaosMonkey:          chaosMonkey,
 	}
 }
 
@@ -67,6 +75,11 @@ func (sc *simpleContext) GetPrometheusFramework() *framework.Framework {
 	return sc.prometheusFramework
 }
 
+// GetPrometheusController returns prometheus controller, nil if the prometheus stack is not set up.
+func (sc *simpleContext) GetPrometheusController() *prometheus.PrometheusController {
+	return sc.prometheusController
+}
+
 // GetState returns current test state.
 func (sc *simpleContext) GetState() *state.State {
 	return sc.state
@@ -91,3 +104,31 @@ func (sc *simpleContext) GetMeasurementManager() *measurement.MeasurementManager
 func (sc *simpleContext) GetChaosMonkey() *chaos.Monkey {
 	return sc.chaosMonkey
 }
+
+// IsTimestampAnnotationEnabled returns whether created and patched objects are annotated
+// with the time of the api call. It is enabled by timestampContext only.
+func (sc *simpleContext) IsTimestampAnnotationEnabled() bool {
+	return false
+}
+
+// timestampContext is a context in which created and patched objects are annotated
+// with the time of the api call.
+type timestampContext struct {
+	Context
+}
+
+// IsTimestampAnnotationEnabled returns true.
+func (tc *timestampContext) IsTimestampAnnotationEnabled() bool {
+	return true
+}
+
+// identityContext is a context which cluster framework impersonates an identity.
+type identityContext struct {
+	Context
+	clusterFramework *framework.Framework
+}
+
+// GetClusterFramework returns cluster framework impersonating the identity.
+func (ic *identityContext) GetClusterFramework() *framework.Framework {
+	return ic.clusterFramework
+}
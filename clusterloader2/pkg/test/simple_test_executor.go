@@ -20,13 +20,19 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/wait"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/klog"
 	"k8s.io/perf-tests/clusterloader2/api"
+	"k8s.io/perf-tests/clusterloader2/pkg/chaos"
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
+	"k8s.io/perf-tests/clusterloader2/pkg/framework/client"
+	"k8s.io/perf-tests/clusterloader2/pkg/measurement"
 	"k8s.io/perf-tests/clusterloader2/pkg/state"
 	"k8s.io/perf-tests/clusterloader2/pkg/util"
 )
@@ -34,6 +40,10 @@ import (
 const (
 	namePlaceholder  = "Name"
 	indexPlaceholder = "Index"
+
+	chaosEventsSummaryName = "ChaosEvents"
+	// watchLatencyMeasurementName is the name of the measurement using timestamp annotations.
+	watchLatencyMeasurementName = "WatchLatency"
 )
 
 type simpleTestExecutor struct{}
@@ -48,11 +58,29 @@ func (ste *simpleTestExecutor) ExecuteTest(ctx Context, conf *api.Config) *error
 	klog.Infof("AutomanagedNamespacePrefix: %s", ctx.GetClusterFramework().GetAutomanagedNamespacePrefix())
 	defer cleanupResources(ctx)
 	ctx.GetTuningSetFactory().Init(conf.TuningSets)
+	if usesMeasurement(conf, watchLatencyMeasurementName) {
+		ctx = &timestampContext{Context: ctx}
+	}
 	stopCh := make(chan struct{})
-	defer close(stopCh)
+	var stopChaosMonkeyOnce sync.Once
+	// stopChaosMonkey stops simulated failures and waits until they are repaired.
+	stopChaosMonkey := func() {
+		stopChaosMonkeyOnce.Do(func() {
+			close(stopCh)
+			ctx.GetChaosMonkey().Wait()
+		})
+	}
+	defer stopChaosMonkey()
 	if err := ctx.GetChaosMonkey().Init(conf.ChaosMonkey, stopCh); err != nil {
 		return errors.NewErrorList(fmt.Errorf("error while creating chaos monkey: %v", err))
 	}
+	identities, err := getImpersonationConfigs(conf.Identities)
+	if err != nil {
+		return errors.NewErrorList(fmt.Errorf("incorrect identities: %v", err))
+	}
+	if err := ctx.GetClusterFramework().SetIdentities(identities); err != nil {
+		return errors.NewErrorList(fmt.Errorf("identities setting failed: %v", err))
+	}
 	automanagedNamespacesList, err := ctx.GetClusterFramework().ListAutomanagedNamespaces()
 	if err != nil {
 		return errors.NewErrorList(fmt.Errorf("automanaged namespaces listing failed: %v", err))
@@ -64,6 +92,24 @@ func (ste *simpleTestExecutor) ExecuteTest(ctx Context, conf *api.Config) *error
 	if err != nil {
 		return errors.NewErrorList(fmt.Errorf("automanaged namespaces creation failed: %v", err))
 	}
+	if len(conf.PrometheusObjects) > 0 {
+		prometheusController := ctx.GetPrometheusController()
+		if prometheusController == nil {
+			return errors.NewErrorList(fmt.Errorf("prometheus objects require prometheus server to be set up"))
+		}
+		objects, err := createPrometheusObjects(ctx, conf.PrometheusObjects)
+		if err != nil {
+			return errors.NewErrorList(err)
+		}
+		defer func() {
+			if err := prometheusController.DeleteTestObjects(objects); err != nil {
+				klog.Errorf("Prometheus objects deletion error: %v", err)
+			}
+		}()
+		if err := prometheusController.ApplyTestObjects(objects); err != nil {
+			return errors.NewErrorList(fmt.Errorf("prometheus objects applying failed: %v", err))
+		}
+	}
 
 	errList := errors.NewErrorList()
 	for i := range conf.Steps {
@@ -75,7 +121,15 @@ func (ste *simpleTestExecutor) ExecuteTest(ctx Context, conf *api.Config) *error
 		}
 	}
 
-	for _, summary := range ctx.GetMeasurementManager().GetSummaries() {
+	// Failures in progress have to be finished before their events are summarized.
+	stopChaosMonkey()
+	summaries := ctx.GetMeasurementManager().GetSummaries()
+	if chaosSummary, err := createChaosEventsSummary(ctx.GetChaosMonkey()); err != nil {
+		errList.Append(fmt.Errorf("chaos events summary creation error: %v", err))
+	} else if chaosSummary != nil {
+		summaries = append(summaries, chaosSummary)
+	}
+	for _, summary := range summaries {
 		if err != nil {
 			errList.Append(fmt.Errorf("printing summary %s error: %v", summary.SummaryName(), err))
 			continue
@@ -132,6 +186,13 @@ func (ste *simpleTestExecutor) ExecuteStep(ctx Context, step *api.Step) *errors.
 func (ste *simpleTestExecutor) ExecutePhase(ctx Context, phase *api.Phase) *errors.ErrorList {
 	// TODO: add tuning set
 	errList := errors.NewErrorList()
+	if phase.Identity != "" {
+		identityFramework, err := ctx.GetClusterFramework().ForIdentity(phase.Identity)
+		if err != nil {
+			return errors.NewErrorList(err)
+		}
+		ctx = &identityContext{Context: ctx, clusterFramework: identityFramework}
+	}
 	nsList := createNamespacesList(ctx, phase.NamespaceRange)
 	tuningSet, err := ctx.GetTuningSetFactory().CreateTuningSet(phase.TuningSet)
 	if err != nil {
@@ -256,10 +317,16 @@ func (ste *simpleTestExecutor) ExecuteObject(ctx Context, object *api.Object, na
 	errList := errors.NewErrorList()
 	switch operation {
 	case CREATE_OBJECT:
+		if ctx.IsTimestampAnnotationEnabled() {
+			client.SetTimestampAnnotation(obj, time.Now())
+		}
 		if err := ctx.GetClusterFramework().CreateObject(namespace, objName, obj); err != nil {
 			errList.Append(fmt.Errorf("namespace %v object %v creation error: %v", namespace, objName, err))
 		}
 	case PATCH_OBJECT:
+		if ctx.IsTimestampAnnotationEnabled() {
+			client.SetTimestampAnnotation(obj, time.Now())
+		}
 		if err := ctx.GetClusterFramework().PatchObject(namespace, objName, obj); err != nil {
 			errList.Append(fmt.Errorf("namespace %v object %v updating error: %v", namespace, objName, err))
 		}
@@ -271,6 +338,18 @@ func (ste *simpleTestExecutor) ExecuteObject(ctx Context, object *api.Object, na
 	return errList
 }
 
+// usesMeasurement checks whether any step of the test executes the given measurement.
+func usesMeasurement(conf *api.Config, method string) bool {
+	for i := range conf.Steps {
+		for j := range conf.Steps[i].Measurements {
+			if conf.Steps[i].Measurements[j].Method == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // verifyBundleCorrectness checks if all bundle objects have the same replica count.
 func verifyBundleCorrectness(instancesStates []*state.InstancesState) error {
 	const uninitialized int32 = -1
@@ -326,6 +405,67 @@ func createNamespacesList(ctx Context, namespaceRange *api.NamespaceRange) []str
 	return nsList
 }
 
+// getImpersonationConfigs converts given identities into impersonation configs mapped by identity names.
+func getImpersonationConfigs(identities []api.Identity) (map[string]restclient.ImpersonationConfig, error) {
+	result := make(map[string]restclient.ImpersonationConfig, len(identities))
+	for _, identity := range identities {
+		if identity.Name == "" {
+			return nil, fmt.Errorf("identity name cannot be empty")
+		}
+		if _, exists := result[identity.Name]; exists {
+			return nil, fmt.Errorf("identity %s defined more than once", identity.Name)
+		}
+		if (identity.User == "") == (identity.ServiceAccount == "") {
+			return nil, fmt.Errorf("identity %s: exactly one of user and serviceAccount should be set", identity.Name)
+		}
+		impersonate := restclient.ImpersonationConfig{
+			UserName: identity.User,
+			Groups:   identity.Groups,
+		}
+		if identity.ServiceAccount != "" {
+			parts := strings.Split(identity.ServiceAccount, "/")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("identity %s: service account %q is not in <namespace>/<name> format", identity.Name, identity.ServiceAccount)
+			}
+			impersonate.UserName = fmt.Sprintf("system:serviceaccount:%s:%s", parts[0], parts[1])
+			impersonate.Groups = append([]string{"system:serviceaccounts", "system:serviceaccounts:" + parts[0]}, identity.Groups...)
+		}
+		result[identity.Name] = impersonate
+	}
+	return result, nil
+}
+
+// createPrometheusObjects creates objects from the templates of the prometheus objects declared in the test.
+func createPrometheusObjects(ctx Context, prometheusObjects []api.PrometheusObject) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, object := range prometheusObjects {
+		mapping := make(map[string]interface{})
+		if object.TemplateFillMap != nil {
+			util.CopyMap(object.TemplateFillMap, mapping)
+		}
+		obj, err := ctx.GetTemplateProvider().TemplateToObject(object.ObjectTemplatePath, mapping)
+		if err != nil {
+			return nil, fmt.Errorf("reading template (%v) error: %v", object.ObjectTemplatePath, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// createChaosEventsSummary creates summary of failures simulated during the test.
+// Returns nil if no failures were simulated.
+func createChaosEventsSummary(monkey *chaos.Monkey) (measurement.Summary, error) {
+	events := monkey.GetEventRecorder().Events()
+	if len(events) == 0 {
+		return nil, nil
+	}
+	content, err := util.PrettyPrintJSON(events)
+	if err != nil {
+		return nil, err
+	}
+	return measurement.CreateSummary(chaosEventsSummaryName, "json", content), nil
+}
+
 func isErrsCritical(*errors.ErrorList) bool {
 	// TODO: define critical errors
 	return false
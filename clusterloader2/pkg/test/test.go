@@ -23,6 +23,7 @@ import (
 	"k8s.io/perf-tests/clusterloader2/pkg/config"
 	"k8s.io/perf-tests/clusterloader2/pkg/errors"
 	"k8s.io/perf-tests/clusterloader2/pkg/framework"
+	"k8s.io/perf-tests/clusterloader2/pkg/prometheus"
 	"k8s.io/perf-tests/clusterloader2/pkg/state"
 )
 
@@ -37,7 +38,8 @@ var (
 )
 
 // RunTest runs test based on provided test configuration.
-func RunTest(clusterFramework, prometheusFramework *framework.Framework, clusterLoaderConfig *config.ClusterLoaderConfig) *errors.ErrorList {
+// Prometheus controller is nil if the prometheus stack is not set up.
+func RunTest(clusterFramework *framework.Framework, prometheusController *prometheus.PrometheusController, clusterLoaderConfig *config.ClusterLoaderConfig) *errors.ErrorList {
 	if clusterFramework == nil {
 		return errors.NewErrorList(fmt.Errorf("framework must be provided"))
 	}
@@ -51,7 +53,7 @@ func RunTest(clusterFramework, prometheusFramework *framework.Framework, cluster
 		return errors.NewErrorList(fmt.Errorf("no Test installed"))
 	}
 
-	ctx := CreateContext(clusterLoaderConfig, clusterFramework, prometheusFramework, state.NewState())
+	ctx := CreateContext(clusterLoaderConfig, clusterFramework, prometheusController, state.NewState())
 	testConfigFilename := filepath.Base(clusterLoaderConfig.TestConfigPath)
 
 	mapping, errList := config.GetMapping(clusterLoaderConfig)
@@ -66,6 +66,16 @@ func GetBool(dict map[string]interface{}, key string) (bool, error) {
 	return getBool(dict, key)
 }
 
+// GetMapArray tries to return value from map cast to array of maps type. If value doesn't exist, error is returned.
+func GetMapArray(dict map[string]interface{}, key string) ([]map[string]interface{}, error) {
+	return getMapArray(dict, key)
+}
+
+// GetStringArray tries to return value from map cast to array of strings type. If value doesn't exist, error is returned.
+func GetStringArray(dict map[string]interface{}, key string) ([]string, error) {
+	return getStringArray(dict, key)
+}
+
 // GetStringOrDefault tries to return value from map cast to string type. If value doesn't exist default value is used.
 func GetStringOrDefault(dict map[string]interface{}, key string, defaultValue string) (string, error) {
 	value, err := getString(dict, key)
@@ -111,6 +121,24 @@ func GetBoolOrDefault(dict map[string]interface{}, key string, defaultValue bool
 	return value, err
 }
 
+// GetMapArrayOrDefault tries to return value from map cast to array of maps type. If value doesn't exist default value is used.
+func GetMapArrayOrDefault(dict map[string]interface{}, key string, defaultValue []map[string]interface{}) ([]map[string]interface{}, error) {
+	value, err := getMapArray(dict, key)
+	if IsErrKeyNotFound(err) {
+		return defaultValue, nil
+	}
+	return value, err
+}
+
+// GetStringArrayOrDefault tries to return value from map cast to array of strings type. If value doesn't exist default value is used.
+func GetStringArrayOrDefault(dict map[string]interface{}, key string, defaultValue []string) ([]string, error) {
+	value, err := getStringArray(dict, key)
+	if IsErrKeyNotFound(err) {
+		return defaultValue, nil
+	}
+	return value, err
+}
+
 func getString(dict map[string]interface{}, key string) (string, error) {
 	value, exists := dict[key]
 	if !exists || value == nil {
@@ -199,6 +227,48 @@ func getBool(dict map[string]interface{}, key string) (bool, error) {
 	return false, fmt.Errorf("type assertion error: %v is not a bool", value)
 }
 
+func getMapArray(dict map[string]interface{}, key string) ([]map[string]interface{}, error) {
+	value, exists := dict[key]
+	if !exists || value == nil {
+		return nil, &ErrKeyNotFound{key}
+	}
+
+	arrayValue, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("type assertion error: %v is not an array", value)
+	}
+	result := make([]map[string]interface{}, 0, len(arrayValue))
+	for _, item := range arrayValue {
+		mapValue, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("type assertion error: %v is not a map", item)
+		}
+		result = append(result, mapValue)
+	}
+	return result, nil
+}
+
+func getStringArray(dict map[string]interface{}, key string) ([]string, error) {
+	value, exists := dict[key]
+	if !exists || value == nil {
+		return nil, &ErrKeyNotFound{key}
+	}
+
+	arrayValue, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("type assertion error: %v is not an array", value)
+	}
+	result := make([]string, 0, len(arrayValue))
+	for _, item := range arrayValue {
+		stringValue, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("type assertion error: %v is not a string", item)
+		}
+		result = append(result, stringValue)
+	}
+	return result, nil
+}
+
 // PrettyPrintJSON converts given data into formatted json.
 func PrettyPrintJSON(data interface{}) (string, error) {
 	output := &bytes.Buffer{}